@@ -0,0 +1,432 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// AlertsConfig is read from the yaml-file passed via --alerts.config, eg:
+//
+//	rules:
+//	  - name: low-peers
+//	    process: beaconnode
+//	    metric: network_peers_connected
+//	    op: "<"
+//	    value: 10
+//	    for: 5m
+//	  - name: low-disk
+//	    process: system
+//	    metric: disk_node_bytes_free
+//	    percent_of: disk_node_bytes_total
+//	    op: "<"
+//	    value: 10
+//	  - name: head-stalled
+//	    process: beaconnode
+//	    metric: sync_beacon_head_slot
+//	    op: unchanged
+//	    intervals: 3
+//	  - name: beaconnode-down
+//	    process: beaconnode
+//	    metric: up
+//	    op: "=="
+//	    value: 0
+//	  - name: validators-dropped
+//	    process: validator
+//	    metric: validator_active
+//	    op: decreased
+//	sinks:
+//	  - type: slack
+//	    url: https://hooks.slack.com/services/...
+type AlertsConfig struct {
+	Rules []AlertRule `yaml:"rules"`
+	Sinks []AlertSink `yaml:"sinks"`
+}
+
+// AlertRule fires if the condition is met for at least the given duration and the given number of consecutive intervals
+type AlertRule struct {
+	Name      string        `yaml:"name"`
+	Process   string        `yaml:"process"`    // process of the record, eg: system, beaconnode, validator, execution
+	Metric    string        `yaml:"metric"`     // json-name of the field in the record, eg: network_peers_connected
+	PercentOf string        `yaml:"percent_of"` // if set, metric is compared as percentage of this field
+	Op        string        `yaml:"op"`         // one of: <, <=, >, >=, ==, !=, unchanged, increased, decreased
+	Value     float64       `yaml:"value"`
+	For       time.Duration `yaml:"for"`
+	Intervals int           `yaml:"intervals"`
+}
+
+// AlertSink describes where notifications are sent to
+type AlertSink struct {
+	Type string `yaml:"type"` // one of: webhook, slack, telegram, email
+	URL  string `yaml:"url"`  // webhook, slack (incoming-webhook-url), telegram (defaults to https://api.telegram.org)
+	// telegram
+	Token  string `yaml:"token"`
+	ChatID string `yaml:"chat_id"`
+	// email
+	SMTPHost string   `yaml:"smtp_host"` // host:port
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+type Alert struct {
+	Rule    string  `json:"rule"`
+	Status  string  `json:"status"` // firing or resolved
+	Process string  `json:"process"`
+	Client  string  `json:"client,omitempty"`
+	Metric  string  `json:"metric"`
+	Value   float64 `json:"value"`
+	Message string  `json:"message"`
+}
+
+type alertState struct {
+	hasLast     bool
+	last        float64
+	ref         float64 // value before the change, increased and decreased stay active until it is reached again
+	activeSince time.Time
+	count       int
+	firing      bool
+}
+
+type alertEngine struct {
+	config  *AlertsConfig
+	mu      sync.Mutex
+	states  map[string]*alertState
+	clients map[string]string // last client_name per process
+	now     func() time.Time
+}
+
+var alerts *alertEngine
+
+var alertHTTPClient = &http.Client{Timeout: time.Second * 10}
+
+func newAlertEngine(path string) (*alertEngine, error) {
+	configBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading alerts-config: %w", err)
+	}
+	config := &AlertsConfig{}
+	err = yaml.UnmarshalStrict(configBytes, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed decoding alerts-config: %w", err)
+	}
+	for i, r := range config.Rules {
+		if r.Name == "" || r.Process == "" || r.Metric == "" {
+			return nil, fmt.Errorf("invalid alert-rule %v: name, process and metric are required", i)
+		}
+		switch r.Op {
+		case "<", "<=", ">", ">=", "==", "!=", "unchanged", "increased", "decreased":
+		default:
+			return nil, fmt.Errorf("invalid alert-rule %v: unknown op: %v", r.Name, r.Op)
+		}
+		record, exists := alertRecordTypes[r.Process]
+		if !exists {
+			return nil, fmt.Errorf("invalid alert-rule %v: unknown process: %v", r.Name, r.Process)
+		}
+		fields := recordFieldNames(record)
+		fields["up"] = false
+		for _, metric := range []string{r.Metric, r.PercentOf} {
+			if metric == "" {
+				continue
+			}
+			extended, exists := fields[metric]
+			if !exists {
+				return nil, fmt.Errorf("invalid alert-rule %v: unknown metric of %v: %v", r.Name, r.Process, metric)
+			}
+			if extended && !options.ServerExtended {
+				logrus.WithFields(logrus.Fields{"rule": r.Name, "metric": metric}).Warn("alert-rule uses an extended field, some of them are only collected with server.extended")
+			}
+		}
+	}
+	for i, s := range config.Sinks {
+		switch s.Type {
+		case "webhook", "slack":
+			if s.URL == "" {
+				return nil, fmt.Errorf("invalid alert-sink %v: url is required", i)
+			}
+		case "telegram":
+			if s.Token == "" || s.ChatID == "" {
+				return nil, fmt.Errorf("invalid alert-sink %v: token and chat_id are required", i)
+			}
+		case "email":
+			if s.SMTPHost == "" || s.From == "" || len(s.To) == 0 {
+				return nil, fmt.Errorf("invalid alert-sink %v: smtp_host, from and to are required", i)
+			}
+		default:
+			return nil, fmt.Errorf("invalid alert-sink %v: unknown type: %v", i, s.Type)
+		}
+	}
+	return &alertEngine{config: config, states: map[string]*alertState{}, clients: map[string]string{}, now: time.Now}, nil
+}
+
+// evaluate checks all rules against the collected records and notifies the sinks about alerts that started firing or got resolved.
+// Every record gets the field up, endpoints whose scrape failed are evaluated as a record with only up = false.
+func (e *alertEngine) evaluate(records []interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	collected := map[string]bool{}
+	recordsFields := []map[string]interface{}{}
+	for _, record := range records {
+		fields, err := recordFields(record)
+		if err != nil {
+			logrus.WithError(err).Error("failed evaluating alert-rules")
+			continue
+		}
+		process, _ := fields["process"].(string)
+		client, _ := fields["client_name"].(string)
+		fields["up"] = true
+		collected[process] = true
+		e.clients[process] = client
+		recordsFields = append(recordsFields, fields)
+	}
+	for name, healthy := range endpointsHealthy() {
+		if !healthy && !collected[name] {
+			recordsFields = append(recordsFields, map[string]interface{}{"process": name, "client_name": e.clients[name], "up": false})
+		}
+	}
+
+	now := e.now()
+	for _, fields := range recordsFields {
+		process, _ := fields["process"].(string)
+		client, _ := fields["client_name"].(string)
+		for i, r := range e.config.Rules {
+			if r.Process != process {
+				continue
+			}
+			value, exists := recordFieldValue(fields, r.Metric)
+			if !exists {
+				continue
+			}
+			if r.PercentOf != "" {
+				total, exists := recordFieldValue(fields, r.PercentOf)
+				if !exists || total == 0 {
+					continue
+				}
+				value = value * 100 / total
+			}
+
+			key := fmt.Sprintf("%v:%v:%v", i, process, client)
+			s, exists := e.states[key]
+			if !exists {
+				s = &alertState{}
+				e.states[key] = s
+			}
+
+			active, ok := r.check(s, value)
+			if active && s.count == 0 {
+				s.ref = s.last
+			}
+			s.hasLast = true
+			s.last = value
+			if !ok {
+				continue
+			}
+			if !active {
+				if s.firing {
+					e.notify(Alert{Rule: r.Name, Status: "resolved", Process: process, Client: client, Metric: r.Metric, Value: value})
+				}
+				s.activeSince = time.Time{}
+				s.count = 0
+				s.firing = false
+				continue
+			}
+			if s.count == 0 {
+				s.activeSince = now
+			}
+			s.count++
+			intervals := r.Intervals
+			if intervals < 1 {
+				intervals = 1
+			}
+			if !s.firing && s.count >= intervals && now.Sub(s.activeSince) >= r.For {
+				s.firing = true
+				e.notify(Alert{Rule: r.Name, Status: "firing", Process: process, Client: client, Metric: r.Metric, Value: value})
+			}
+		}
+	}
+}
+
+// check returns whether the condition of the rule is met, ok is false if it can not be evaluated yet
+func (r *AlertRule) check(s *alertState, value float64) (active, ok bool) {
+	switch r.Op {
+	case "<":
+		return value < r.Value, true
+	case "<=":
+		return value <= r.Value, true
+	case ">":
+		return value > r.Value, true
+	case ">=":
+		return value >= r.Value, true
+	case "==":
+		return value == r.Value, true
+	case "!=":
+		return value != r.Value, true
+	}
+	if !s.hasLast {
+		return false, false
+	}
+	switch r.Op {
+	case "unchanged":
+		return value == s.last, true
+	}
+	// while active, compare with the value before the change instead of the previous one
+	ref := s.last
+	if s.count > 0 {
+		ref = s.ref
+	}
+	switch r.Op {
+	case "increased":
+		return value > ref, true
+	case "decreased":
+		return value < ref, true
+	}
+	return false, false
+}
+
+func (e *alertEngine) notify(a Alert) {
+	a.Message = fmt.Sprintf("[%v] %v: %v", strings.ToUpper(a.Status), a.Rule, a.Process)
+	if a.Client != "" {
+		a.Message += fmt.Sprintf(" (%v)", a.Client)
+	}
+	a.Message += fmt.Sprintf(" %v = %v", a.Metric, a.Value)
+	logrus.WithFields(logrus.Fields{"rule": a.Rule, "status": a.Status, "process": a.Process, "metric": a.Metric, "value": a.Value}).Warn("alert")
+
+	for _, s := range e.config.Sinks {
+		go func(s AlertSink) {
+			err := s.send(a)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{"error": err, "sink": s.Type, "rule": a.Rule}).Error("failed sending alert")
+			}
+		}(s)
+	}
+}
+
+func (s *AlertSink) send(a Alert) error {
+	switch s.Type {
+	case "webhook":
+		return postAlertJSON(s.URL, a)
+	case "slack":
+		return postAlertJSON(s.URL, map[string]string{"text": a.Message})
+	case "telegram":
+		url := s.URL
+		if url == "" {
+			url = "https://api.telegram.org"
+		}
+		return postAlertJSON(fmt.Sprintf("%v/bot%v/sendMessage", strings.TrimSuffix(url, "/"), s.Token), map[string]string{"chat_id": s.ChatID, "text": a.Message})
+	case "email":
+		var auth smtp.Auth
+		if s.Username != "" {
+			auth = smtp.PlainAuth("", s.Username, s.Password, strings.Split(s.SMTPHost, ":")[0])
+		}
+		msg := fmt.Sprintf("From: %v\r\nTo: %v\r\nSubject: %v\r\n\r\n%v\r\n", s.From, strings.Join(s.To, ", "), a.Message, a.Message)
+		return smtp.SendMail(s.SMTPHost, auth, s.From, s.To, []byte(msg))
+	}
+	return fmt.Errorf("unknown alert-sink-type: %v", s.Type)
+}
+
+func postAlertJSON(endpoint string, payload interface{}) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(payloadJSON))
+	if err != nil {
+		return redactURLError(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := alertHTTPClient.Do(req)
+	if err != nil {
+		return redactURLError(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("got error-response: %v: %s", res.StatusCode, body)
+	}
+	return nil
+}
+
+// redactURLError removes the url from err, the urls of the sinks contain secrets (eg: the telegram-token
+// or the slack-webhook) which must not be logged
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%v: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
+
+// alertRecordTypes are the record-types by process, the rules are validated against their fields
+var alertRecordTypes = map[string]reflect.Type{
+	"system":     reflect.TypeOf(SystemData{}),
+	"beaconnode": reflect.TypeOf(BeaconnodeData{}),
+	"validator":  reflect.TypeOf(ValidatorData{}),
+	"execution":  reflect.TypeOf(ExecutionData{}),
+}
+
+// recordFieldNames returns the json-names of the fields of a record-type and whether they are extended fields
+func recordFieldNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	var walk func(t reflect.Type, extended bool)
+	walk = func(t reflect.Type, extended bool) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.Anonymous {
+				if f.Type.Kind() == reflect.Ptr {
+					walk(f.Type.Elem(), true)
+				} else {
+					walk(f.Type, extended)
+				}
+				continue
+			}
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			names[name] = extended
+		}
+	}
+	walk(t, false)
+	return names
+}
+
+// recordFields returns the fields of a record by their json-names
+func recordFields(record interface{}) (map[string]interface{}, error) {
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	err = json.Unmarshal(recordJSON, &fields)
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func recordFieldValue(fields map[string]interface{}, name string) (float64, bool) {
+	switch v := fields[name].(type) {
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
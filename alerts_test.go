@@ -0,0 +1,120 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlertEngineEvaluate(t *testing.T) {
+	type step struct {
+		value  uint64
+		after  time.Duration // time since the previous step
+		firing bool
+	}
+	tests := []struct {
+		name  string
+		rule  AlertRule
+		steps []step
+	}{
+		{
+			name: "comparison fires immediately",
+			rule: AlertRule{Metric: "network_peers_connected", Op: "<", Value: 10},
+			steps: []step{
+				{value: 20, firing: false},
+				{value: 5, firing: true},
+				{value: 20, firing: false},
+			},
+		},
+		{
+			name: "unchanged fires after 3 intervals",
+			rule: AlertRule{Metric: "sync_beacon_head_slot", Op: "unchanged", Intervals: 3},
+			steps: []step{
+				{value: 100, firing: false},
+				{value: 100, firing: false},
+				{value: 100, firing: false},
+				{value: 100, firing: true},
+				{value: 101, firing: false},
+				{value: 101, firing: false},
+			},
+		},
+		{
+			name: "increased stays active until the previous value is reached",
+			rule: AlertRule{Metric: "network_peers_connected", Op: "increased"},
+			steps: []step{
+				{value: 10, firing: false},
+				{value: 15, firing: true},
+				{value: 12, firing: true},
+				{value: 11, firing: true},
+				{value: 10, firing: false},
+				{value: 10, firing: false},
+			},
+		},
+		{
+			name: "decreased stays active until the previous value is reached",
+			rule: AlertRule{Metric: "network_peers_connected", Op: "decreased"},
+			steps: []step{
+				{value: 10, firing: false},
+				{value: 5, firing: true},
+				{value: 8, firing: true},
+				{value: 12, firing: false},
+				{value: 11, firing: true},
+			},
+		},
+		{
+			name: "for delays firing until the duration passed",
+			rule: AlertRule{Metric: "network_peers_connected", Op: "<", Value: 10, For: time.Minute * 5},
+			steps: []step{
+				{value: 5, firing: false},
+				{value: 5, after: time.Minute * 3, firing: false},
+				{value: 5, after: time.Minute * 2, firing: true},
+				{value: 20, after: time.Minute, firing: false},
+			},
+		},
+		{
+			name: "for restarts after recovery",
+			rule: AlertRule{Metric: "network_peers_connected", Op: "<", Value: 10, For: time.Minute * 5},
+			steps: []step{
+				{value: 5, firing: false},
+				{value: 20, after: time.Minute * 4, firing: false},
+				{value: 5, after: time.Minute, firing: false},
+				{value: 5, after: time.Minute * 4, firing: false},
+				{value: 5, after: time.Minute, firing: true},
+			},
+		},
+		{
+			name: "for and intervals must both be met",
+			rule: AlertRule{Metric: "network_peers_connected", Op: "<", Value: 10, For: time.Minute, Intervals: 3},
+			steps: []step{
+				{value: 5, firing: false},
+				{value: 5, after: time.Minute * 2, firing: false},
+				{value: 5, after: time.Second, firing: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Name = "test"
+			tt.rule.Process = "beaconnode"
+			now := time.Unix(1600000000, 0)
+			e := &alertEngine{
+				config:  &AlertsConfig{Rules: []AlertRule{tt.rule}},
+				states:  map[string]*alertState{},
+				clients: map[string]string{},
+				now:     func() time.Time { return now },
+			}
+			for i, s := range tt.steps {
+				now = now.Add(s.after)
+				d := &BeaconnodeData{}
+				d.Process = "beaconnode"
+				d.ClientName = "prysm"
+				d.NetworkPeersConnected = s.value
+				d.SyncBeaconHeadSlot = s.value
+				e.evaluate([]interface{}{d})
+				firing := e.states["0:beaconnode:prysm"].firing
+				if firing != s.firing {
+					t.Errorf("step %v (value %v): got firing %v, expected %v", i, s.value, firing, s.firing)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	promModel "github.com/prometheus/client_model/go"
+	"github.com/sirupsen/logrus"
+)
+
+// detectedClients keeps the last detected client per endpoint to log client switches
+var detectedClients = struct {
+	sync.Mutex
+	m map[string]string
+}{m: map[string]string{}}
+
+type beaconNodeVersionResponse struct {
+	Data struct {
+		Version string `json:"version"`
+	} `json:"data"`
+}
+
+// detectClient detects the client by the metric-families it exports, returns an empty string if unknown
+func detectClient(metrics map[string]*promModel.MetricFamily) string {
+	if _, exists := metrics["prysm_version"]; exists {
+		return "prysm"
+	}
+	if _, exists := metrics["lighthouse_info"]; exists {
+		return "lighthouse"
+	}
+	for name := range metrics {
+		switch {
+		case strings.HasPrefix(name, "nbc_"), strings.HasPrefix(name, "nimbus_"):
+			return "nimbus"
+		case strings.HasPrefix(name, "teku_"):
+			return "teku"
+		case strings.HasPrefix(name, "lodestar_"):
+			return "lodestar"
+		}
+	}
+	return ""
+}
+
+// getBeaconAPIClient detects the client by the version of the beacon-api, eg: Prysm/v5.0.0/...
+func getBeaconAPIClient(ctx context.Context, endpoint string, auth *EndpointAuth) (string, error) {
+	res := &beaconNodeVersionResponse{}
+	err := getAPIJSON(ctx, strings.TrimSuffix(endpoint, "/")+"/eth/v1/node/version", auth, "", res)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(strings.Split(res.Data.Version, "/")[0]), nil
+}
+
+// getAutoDetectedData detects the client of the endpoint on every scrape and uses the matching collector,
+// so the exporter keeps working if the client behind the endpoint is switched
+func getAutoDetectedData(ctx context.Context, c ClientEndpoint, ts uint64) (interface{}, error) {
+	metrics, err := getMetrics(ctx, c.Address, &c.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	client := detectClient(metrics)
+	if client == "" && c.Name == "beaconnode" && c.BeaconAPIAddress != "" {
+		client, err = getBeaconAPIClient(ctx, c.BeaconAPIAddress, &c.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("failed detecting client via beacon-api: %w", err)
+		}
+	}
+
+	detectedClients.Lock()
+	if detectedClients.m[c.Name] != client {
+		logrus.WithFields(logrus.Fields{"endpoint": c.Name, "address": c.Address, "client": client, "previous": detectedClients.m[c.Name]}).Info("detected client")
+		detectedClients.m[c.Name] = client
+	}
+	detectedClients.Unlock()
+
+	switch {
+	case c.Name == "beaconnode" && client == "prysm":
+		return newPrysmBeaconnodeData(c, metrics, ts), nil
+	case c.Name == "beaconnode" && client == "nimbus":
+		return newNimbusBeaconnodeData(c, metrics, ts), nil
+	case c.Name == "validator" && client == "prysm":
+		data := newPrysmValidatorData(c, metrics, ts)
+		if c.KeymanagerAddress != "" {
+			err = setValidatorDataFromAPI(ctx, c, data)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return data, nil
+	case c.Name == "validator" && c.KeymanagerAddress != "":
+		// the client detected from the metrics saves getValidatorAPIData another request
+		return getValidatorAPIData(ctx, c, client, ts)
+	case client == "":
+		return nil, fmt.Errorf("failed detecting client of %v, set %v.type", c.Address, c.Name)
+	}
+	return nil, fmt.Errorf("detected %v at %v, which is not supported as %v by the exporter", client, c.Address, c.Name)
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// cgroupV1MemoryUnlimited is the memory-limit of cgroup v1 if there is none (rounded to pages)
+const cgroupV1MemoryUnlimited = 1 << 62
+
+func cgroupRoot() string {
+	sys := os.Getenv("HOST_SYS")
+	if sys == "" {
+		sys = "/sys"
+	}
+	return filepath.Join(sys, "fs", "cgroup")
+}
+
+func isCgroupV2(root string) bool {
+	_, err := os.Stat(filepath.Join(root, "cgroup.controllers"))
+	return err == nil
+}
+
+// dockerContainerIDs caches the ids of the containers per cgroup, an id is looked up again once
+// its cgroup is gone (eg: the container was recreated)
+var dockerContainerIDs = struct {
+	sync.Mutex
+	m map[string]string
+}{m: map[string]string{}}
+
+// resolveCgroup returns the cgroup-path of the given cgroup, which is either a path relative to
+// the cgroup-root (eg: /system.slice/beacon-chain.service) or docker:<container-name>
+func resolveCgroup(ctx context.Context, cgroup string) ([]string, error) {
+	if !strings.HasPrefix(cgroup, "docker:") {
+		return []string{cgroup}, nil
+	}
+	dockerContainerIDs.Lock()
+	id, exists := dockerContainerIDs.m[cgroup]
+	dockerContainerIDs.Unlock()
+	if !exists {
+		var err error
+		id, err = getDockerContainerID(ctx, options.CgroupDockerSocket, strings.TrimPrefix(cgroup, "docker:"))
+		if err != nil {
+			return nil, fmt.Errorf("failed getting id of container: %w", err)
+		}
+		dockerContainerIDs.Lock()
+		dockerContainerIDs.m[cgroup] = id
+		dockerContainerIDs.Unlock()
+	}
+	// the path depends on the cgroup-driver of docker (systemd or cgroupfs)
+	return []string{"/system.slice/docker-" + id + ".scope", "/docker/" + id}, nil
+}
+
+// getCgroupData reads the cpu-quota, the memory-limit and -usage and the oom-events of the cgroup
+func getCgroupData(ctx context.Context, cgroup string) (*ProcessCgroupData, error) {
+	paths, err := resolveCgroup(ctx, cgroup)
+	if err != nil {
+		return nil, err
+	}
+	root := cgroupRoot()
+	v2 := isCgroupV2(root)
+	for _, p := range paths {
+		if v2 {
+			if _, err := os.Stat(filepath.Join(root, p)); err == nil {
+				return getCgroupV2Data(filepath.Join(root, p))
+			}
+		} else if _, err := os.Stat(filepath.Join(root, "memory", p)); err == nil {
+			return getCgroupV1Data(root, p)
+		}
+	}
+	dockerContainerIDs.Lock()
+	delete(dockerContainerIDs.m, cgroup)
+	dockerContainerIDs.Unlock()
+	return nil, fmt.Errorf("cgroup %v not found in %v", cgroup, root)
+}
+
+func getCgroupV2Data(dir string) (*ProcessCgroupData, error) {
+	data := &ProcessCgroupData{}
+
+	cpuMax, err := readCgroupFile(dir, "cpu.max")
+	if err == nil {
+		fields := strings.Fields(cpuMax)
+		if len(fields) == 2 && fields[0] != "max" {
+			quota, err1 := strconv.ParseFloat(fields[0], 64)
+			period, err2 := strconv.ParseFloat(fields[1], 64)
+			if err1 == nil && err2 == nil && period > 0 {
+				data.CgroupCPUQuotaCores = quota / period
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	cpuStat, err := readCgroupKeyValues(dir, "cpu.stat")
+	if err != nil {
+		return nil, err
+	}
+	data.CgroupCPUMillisecondsTotal = cpuStat["usage_usec"] / 1000
+
+	memoryMax, err := readCgroupFile(dir, "memory.max")
+	if err != nil {
+		return nil, err
+	}
+	if memoryMax != "max" {
+		data.CgroupMemoryLimitBytes, err = strconv.ParseUint(memoryMax, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing memory.max: %w", err)
+		}
+	}
+	data.CgroupMemoryUsageBytes, err = readCgroupUint(dir, "memory.current")
+	if err != nil {
+		return nil, err
+	}
+	memoryEvents, err := readCgroupKeyValues(dir, "memory.events")
+	if err != nil {
+		return nil, err
+	}
+	data.CgroupOOMKillsTotal = memoryEvents["oom_kill"]
+	return data, nil
+}
+
+func getCgroupV1Data(root, cgroup string) (*ProcessCgroupData, error) {
+	data := &ProcessCgroupData{}
+
+	cpuDir := filepath.Join(root, "cpu", cgroup)
+	quota, err := readCgroupFile(cpuDir, "cpu.cfs_quota_us")
+	if err == nil && quota != "-1" {
+		q, err1 := strconv.ParseFloat(quota, 64)
+		period, err2 := readCgroupUint(cpuDir, "cpu.cfs_period_us")
+		if err1 == nil && err2 == nil && period > 0 {
+			data.CgroupCPUQuotaCores = q / float64(period)
+		}
+	}
+	usage, err := readCgroupUint(filepath.Join(root, "cpuacct", cgroup), "cpuacct.usage")
+	if err == nil {
+		data.CgroupCPUMillisecondsTotal = usage / 1e6
+	}
+
+	memoryDir := filepath.Join(root, "memory", cgroup)
+	limit, err := readCgroupUint(memoryDir, "memory.limit_in_bytes")
+	if err != nil {
+		return nil, err
+	}
+	if limit < cgroupV1MemoryUnlimited {
+		data.CgroupMemoryLimitBytes = limit
+	}
+	data.CgroupMemoryUsageBytes, err = readCgroupUint(memoryDir, "memory.usage_in_bytes")
+	if err != nil {
+		return nil, err
+	}
+	// oom_kill is only reported by newer kernels
+	oomControl, err := readCgroupKeyValues(memoryDir, "memory.oom_control")
+	if err == nil {
+		data.CgroupOOMKillsTotal = oomControl["oom_kill"]
+	}
+	return data, nil
+}
+
+func readCgroupFile(dir, name string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func readCgroupUint(dir, name string) (uint64, error) {
+	s, err := readCgroupFile(dir, name)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed parsing %v: %w", name, err)
+	}
+	return v, nil
+}
+
+// readCgroupKeyValues reads files with lines of "key value", like cpu.stat and memory.events
+func readCgroupKeyValues(dir, name string) (map[string]uint64, error) {
+	s, err := readCgroupFile(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]uint64{}
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[fields[0]] = v
+	}
+	return values, nil
+}
+
+// setCgroupData sets the cgroup-stats of the endpoint on the extended fields of the collected data
+func setCgroupData(ctx context.Context, c ClientEndpoint, d interface{}) {
+	if c.Cgroup == "" {
+		return
+	}
+	var p *ProcessData
+	switch d := d.(type) {
+	case *BeaconnodeData:
+		p = &d.ProcessData
+	case *ValidatorData:
+		p = &d.ProcessData
+	case *ExecutionData:
+		p = &d.ProcessData
+	default:
+		return
+	}
+	data, err := getCgroupData(ctx, c.Cgroup)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"error": err, "cgroup": c.Cgroup, "flag": c.Name + ".cgroup"}).Error("failed getting cgroup data")
+		return
+	}
+	p.ProcessCgroupData = data
+}
+
+// getDockerContainerID returns the id of the container with the given name via the docker-api
+func getDockerContainerID(ctx context.Context, socket, name string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", "http://docker/containers/"+url.PathEscape(name)+"/json", nil)
+	if err != nil {
+		return "", err
+	}
+	res, err := getDockerHTTPClient(socket).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("got status %v from docker-api", res.StatusCode)
+	}
+	container := struct {
+		ID string `json:"Id"`
+	}{}
+	err = json.NewDecoder(res.Body).Decode(&container)
+	if err != nil {
+		return "", fmt.Errorf("failed decoding container: %w", err)
+	}
+	return container.ID, nil
+}
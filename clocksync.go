@@ -0,0 +1,37 @@
+package main
+
+import (
+	"math"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// clockOffsetTolerance is the kernel-offset in seconds above which the clock is considered off,
+// duties of validators are due within seconds of the start of the slot
+const clockOffsetTolerance = 0.5
+
+// clockState keeps whether the clock was off on the last check to only log changes
+var clockState = struct {
+	sync.Mutex
+	checked bool
+	off     bool
+}{}
+
+// checkClockSynced warns if the clock is not synchronized or the offset exceeds clockOffsetTolerance,
+// since the timestamps of the records and the duties of the validators rely on the clock
+func checkClockSynced(d *SystemClockData) {
+	off := !d.MiscNodeClockSynced || math.Abs(d.MiscNodeClockKernelOffsetSeconds) > clockOffsetTolerance
+	clockState.Lock()
+	defer clockState.Unlock()
+	if clockState.checked && clockState.off == off {
+		return
+	}
+	clockState.checked, clockState.off = true, off
+	fields := logrus.Fields{"synced": d.MiscNodeClockSynced, "offset": d.MiscNodeClockKernelOffsetSeconds, "maxError": d.MiscNodeClockMaxErrorSeconds}
+	if off {
+		logrus.WithFields(fields).Warn("system clock is not synchronized, check ntpd, chrony or systemd-timesyncd: timestamps and validator duties may be off")
+	} else {
+		logrus.WithFields(fields).Info("system clock is synchronized")
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// status-flags of adjtimex, see: include/uapi/linux/timex.h
+const (
+	adjtimexStatusUnsync = 0x0040 // clock is not synchronized
+	adjtimexStatusNano   = 0x2000 // offset is in nanoseconds instead of microseconds
+)
+
+// getClockData reads the synchronization-state of the clock maintained by ntpd, chrony or
+// systemd-timesyncd from the kernel via adjtimex
+func getClockData() (*SystemClockData, error) {
+	tx := &unix.Timex{}
+	state, err := unix.Adjtimex(tx)
+	if err != nil {
+		return nil, err
+	}
+	offsetUnit := 1e-6
+	if tx.Status&adjtimexStatusNano != 0 {
+		offsetUnit = 1e-9
+	}
+	return &SystemClockData{
+		MiscNodeClockSynced:                tx.Status&adjtimexStatusUnsync == 0 && state != unix.TIME_ERROR,
+		MiscNodeClockKernelOffsetSeconds:   float64(tx.Offset) * offsetUnit,
+		MiscNodeClockMaxErrorSeconds:       float64(tx.Maxerror) * 1e-6,
+		MiscNodeClockEstimatedErrorSeconds: float64(tx.Esterror) * 1e-6,
+	}, nil
+}
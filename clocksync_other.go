@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func getClockData() (*SystemClockData, error) {
+	return nil, fmt.Errorf("clock synchronization monitoring is not supported on %v", runtime.GOOS)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/sirupsen/logrus"
+)
+
+// serverEncoding is the Content-Encoding negotiated with the server if --server.compression=auto.
+// It starts without compression, switches to the best encoding the server announces via the
+// Accept-Encoding-header and falls back if the server responds to a compressed request with
+// 415 Unsupported Media Type or 400 Bad Request.
+var serverEncoding = struct {
+	sync.Mutex
+	encoding string
+	rejected map[string]bool
+}{encoding: "none", rejected: map[string]bool{}}
+
+func getServerEncoding() string {
+	if options.ServerCompression != "auto" {
+		return options.ServerCompression
+	}
+	serverEncoding.Lock()
+	defer serverEncoding.Unlock()
+	return serverEncoding.encoding
+}
+
+// negotiateServerEncoding updates the negotiated encoding after a response of the server to
+// a request with the given encoding and returns true if the request should be retried
+func negotiateServerEncoding(encoding string, res *http.Response) bool {
+	if options.ServerCompression != "auto" {
+		return false
+	}
+	serverEncoding.Lock()
+	defer serverEncoding.Unlock()
+
+	accepted := map[string]bool{}
+	for _, e := range strings.Split(res.Header.Get("Accept-Encoding"), ",") {
+		e = strings.TrimSpace(strings.Split(e, ";")[0])
+		if e != "" {
+			accepted[e] = true
+		}
+	}
+
+	rejected := encoding != "none" && (res.StatusCode == http.StatusUnsupportedMediaType || res.StatusCode == http.StatusBadRequest)
+	if rejected {
+		serverEncoding.rejected[encoding] = true
+	}
+	next := "none"
+	for _, e := range []string{"zstd", "gzip"} {
+		if accepted[e] && !serverEncoding.rejected[e] {
+			next = e
+			break
+		}
+	}
+	if rejected {
+		logrus.WithFields(logrus.Fields{"rejected": encoding, "next": next}).Warn("server rejected compression, falling back")
+		serverEncoding.encoding = next
+		return true
+	}
+	if next != serverEncoding.encoding && next != "none" {
+		logrus.WithFields(logrus.Fields{"encoding": next}).Info("server supports compression, switching")
+		serverEncoding.encoding = next
+	}
+	return false
+}
+
+// compressPayload compresses the payload with the given Content-Encoding
+func compressPayload(encoding string, payload []byte) ([]byte, error) {
+	switch encoding {
+	case "none":
+		return payload, nil
+	case "gzip":
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write(payload)
+		if err != nil {
+			return nil, err
+		}
+		err = w.Close()
+		if err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case "zstd":
+		w, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer w.Close()
+		return w.EncodeAll(payload, nil), nil
+	}
+	return nil, fmt.Errorf("unknown compression: %v", encoding)
+}
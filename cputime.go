@@ -0,0 +1,68 @@
+package main
+
+import (
+	"math"
+
+	"github.com/shirou/gopsutil/cpu"
+)
+
+// cpuSeconds keeps the cpu-times in full precision, the spec-fields are truncated to whole seconds
+type cpuSeconds struct {
+	System float64 // sum of all cpu-times, see getSystemData
+	User   float64
+	IOWait float64
+	Idle   float64
+}
+
+// newCPUSeconds sums the cpu-times of all cpus
+func newCPUSeconds(times []cpu.TimesStat) cpuSeconds {
+	s := cpuSeconds{}
+	for _, t := range times {
+		s.Idle += t.Idle
+		s.User += t.User
+		s.IOWait += t.Iowait
+		// note: currently beaconcha.in expects this to be everything
+		s.System += t.System + t.Iowait + t.User + t.Idle
+	}
+	return s
+}
+
+// busy returns the cpu-time which was neither idle nor waiting for io
+func (s cpuSeconds) busy() float64 {
+	return s.System - s.Idle - s.IOWait
+}
+
+// cpuUtilizationPercent returns the cpu-utilization from the increase of the busy and the total
+// cpu-time, ok is false if the times went backwards or did not advance
+func cpuUtilizationPercent(busyDelta, totalDelta float64) (float64, bool) {
+	if totalDelta <= 0 || busyDelta < 0 || busyDelta > totalDelta {
+		return 0, false
+	}
+	return busyDelta / totalDelta * 100, true
+}
+
+func secondsToMilliseconds(seconds float64) uint64 {
+	return uint64(math.Round(seconds * 1000))
+}
+
+// setCPUNodeSeconds truncates the sums of the cpu-times only once for the spec-fields instead of
+// truncating the times of every cpu, the milliseconds are sent as extended fields
+func (d *SystemData) setCPUNodeSeconds(s cpuSeconds) {
+	d.cpuNodeSeconds = s
+	d.CPUNodeSystemSecondsTotal = uint64(s.System)
+	d.CPUNodeUserSecondsTotal = uint64(s.User)
+	d.CPUNodeIOWaitSecondsTotal = uint64(s.IOWait)
+	d.CPUNodeIdleSecondsTotal = uint64(s.Idle)
+	d.SystemCPUData = &SystemCPUData{
+		CPUNodeSystemMillisecondsTotal: secondsToMilliseconds(s.System),
+		CPUNodeUserMillisecondsTotal:   secondsToMilliseconds(s.User),
+		CPUNodeIOWaitMillisecondsTotal: secondsToMilliseconds(s.IOWait),
+		CPUNodeIdleMillisecondsTotal:   secondsToMilliseconds(s.Idle),
+	}
+}
+
+// setCPUProcessSeconds sets the cpu-time of the process, the milliseconds are sent as extended fields
+func (d *ProcessData) setCPUProcessSeconds(seconds float64) {
+	d.CPUProcessSecondsTotal = uint64(seconds)
+	d.ProcessCPUData = &ProcessCPUData{CPUProcessMillisecondsTotal: secondsToMilliseconds(seconds)}
+}
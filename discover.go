@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// discoveryPort is a well-known metrics-port of a client
+type discoveryPort struct {
+	Port    int
+	Process string // beaconnode or validator
+}
+
+// discoveryPorts are probed in order, the first endpoint found per process is used
+var discoveryPorts = []discoveryPort{
+	{Port: 8080, Process: "beaconnode"}, // prysm
+	{Port: 8081, Process: "validator"},  // prysm
+	{Port: 8008, Process: "beaconnode"}, // nimbus, teku, lodestar
+	{Port: 5054, Process: "beaconnode"}, // lighthouse
+	{Port: 5064, Process: "validator"},  // lighthouse, lodestar
+}
+
+var discoveryHTTPClient = &http.Client{Timeout: time.Second * 2}
+
+type dockerContainer struct {
+	Names           []string `json:"Names"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			IPAddress string `json:"IPAddress"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// discoverEndpoints probes the well-known metrics-ports on localhost and, if dockerSocket is
+// not empty, on the containers of the docker-host and sets the addresses and types of the
+// beaconnode and the validator if they are not set yet. Endpoints of another client than an
+// explicitly set type are skipped.
+func discoverEndpoints(dockerSocket string) {
+	hosts := []string{"localhost"}
+	if dockerSocket != "" {
+		containerHosts, err := getDockerContainerHosts(dockerSocket)
+		if err != nil {
+			logrus.WithError(err).Error("failed discovering docker containers")
+		}
+		hosts = append(hosts, containerHosts...)
+	}
+
+	for _, host := range hosts {
+		for _, p := range discoveryPorts {
+			if (p.Process == "beaconnode" && options.BeaconnodeAddress != "") || (p.Process == "validator" && options.ValidatorAddress != "") {
+				continue
+			}
+			address := fmt.Sprintf("http://%s/metrics", net.JoinHostPort(host, fmt.Sprint(p.Port)))
+			client, err := probeMetricsEndpoint(address)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{"address": address, "error": err}).Debug("no metrics-endpoint found")
+				continue
+			}
+			logger := logrus.WithFields(logrus.Fields{"address": address, "client": client, "process": p.Process})
+			clientType := &options.BeaconnodeType
+			if p.Process == "validator" {
+				clientType = &options.ValidatorType
+			}
+			if *clientType != "auto" && *clientType != client {
+				logger.WithFields(logrus.Fields{"type": *clientType}).Warn("discovered metrics-endpoint of another client than the configured type, skipping it")
+				continue
+			}
+			switch {
+			case p.Process == "beaconnode" && (client == "prysm" || client == "nimbus"):
+				options.BeaconnodeAddress = address
+			case p.Process == "validator" && client == "prysm":
+				options.ValidatorAddress = address
+			default:
+				logger.Warn("discovered metrics-endpoint of unsupported client, it likely has its own metrics-exporter built in")
+				continue
+			}
+			*clientType = client
+			logger.Info("discovered metrics-endpoint")
+		}
+	}
+}
+
+// probeMetricsEndpoint returns the detected client of the metrics-endpoint at address
+func probeMetricsEndpoint(address string) (string, error) {
+	req, err := http.NewRequest("GET", address, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", scrapeAcceptHeader)
+	res, err := discoveryHTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("got status %v", res.StatusCode)
+	}
+	metrics, err := parseMetrics(res)
+	if err != nil {
+		return "", err
+	}
+	client := detectClient(metrics)
+	if client == "" {
+		return "", fmt.Errorf("unknown client")
+	}
+	return client, nil
+}
+
+// dockerHTTPClients are the clients for the docker-api per unix-socket
+var dockerHTTPClients = struct {
+	sync.Mutex
+	m map[string]*http.Client
+}{m: map[string]*http.Client{}}
+
+// getDockerHTTPClient returns the client for the docker-api at the given unix-socket, the host of
+// the urls is ignored
+func getDockerHTTPClient(socket string) *http.Client {
+	dockerHTTPClients.Lock()
+	defer dockerHTTPClients.Unlock()
+	client, exists := dockerHTTPClients.m[socket]
+	if !exists {
+		client = &http.Client{
+			Timeout: time.Second * 5,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+				},
+			},
+		}
+		dockerHTTPClients.m[socket] = client
+	}
+	return client
+}
+
+// getDockerContainerHosts returns the ip-addresses of all running containers via the docker-api
+func getDockerContainerHosts(socket string) ([]string, error) {
+	res, err := getDockerHTTPClient(socket).Get("http://docker/containers/json")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got status %v from docker-api", res.StatusCode)
+	}
+	containers := []dockerContainer{}
+	err = json.NewDecoder(res.Body).Decode(&containers)
+	if err != nil {
+		return nil, fmt.Errorf("failed decoding containers: %w", err)
+	}
+
+	hosts := []string{}
+	for _, c := range containers {
+		for _, n := range c.NetworkSettings.Networks {
+			if n.IPAddress != "" {
+				logrus.WithFields(logrus.Fields{"container": strings.Join(c.Names, ","), "ip": n.IPAddress}).Debug("discovered docker container")
+				hosts = append(hosts, n.IPAddress)
+			}
+		}
+	}
+	return hosts, nil
+}
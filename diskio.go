@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+// isWholeDisk returns true if the block-device is a physical disk, partitions and virtual
+// devices (eg: loop, device-mapper) are excluded since their io is already counted by their disks.
+// If sysfs is not available (eg: not on linux) all devices are counted.
+func isWholeDisk(name string) bool {
+	sys := os.Getenv("HOST_SYS")
+	if sys == "" {
+		sys = "/sys"
+	}
+	if _, err := os.Stat(filepath.Join(sys, "block")); err != nil {
+		return true
+	}
+	target, err := filepath.EvalSymlinks(filepath.Join(sys, "block", name))
+	if err != nil {
+		// partitions are not listed in /sys/block
+		return false
+	}
+	return !strings.Contains(target, "/devices/virtual/")
+}
+
+// getDiskIOCounters returns the io-counters summed over all whole disks
+func getDiskIOCounters() (disk.IOCountersStat, error) {
+	total := disk.IOCountersStat{Name: "all"}
+	ioCounters, err := disk.IOCounters()
+	if err != nil {
+		return total, err
+	}
+	for name, c := range ioCounters {
+		if !isWholeDisk(name) {
+			continue
+		}
+		total.ReadCount += c.ReadCount
+		total.WriteCount += c.WriteCount
+		total.IoTime += c.IoTime
+	}
+	return total, nil
+}
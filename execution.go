@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// executionMetricNames maps the values of ExecutionData to the metric-names the
+// execution clients expose on their prometheus-endpoints
+type executionMetricNames struct {
+	ClientName string
+	Peers      string
+	HeadBlock  string
+	ChainDB    []string // summed up
+}
+
+var executionClientMetricNames = map[ClientType]executionMetricNames{
+	GethExecutionClientType: {
+		ClientName: "geth",
+		Peers:      "p2p_peers",
+		HeadBlock:  "chain_head_block",
+		ChainDB:    []string{"eth_db_chaindata_disk_size"},
+	},
+	NethermindExecutionClientType: {
+		ClientName: "nethermind",
+		Peers:      "nethermind_sync_peers",
+		HeadBlock:  "nethermind_blocks",
+		ChainDB:    []string{"nethermind_state_db_size", "nethermind_blocks_db_size", "nethermind_headers_db_size", "nethermind_receipts_db_size", "nethermind_code_db_size"},
+	},
+	// besu does not report the size of its database, disk_chaindata_bytes_total is always 0
+	BesuExecutionClientType: {
+		ClientName: "besu",
+		Peers:      "ethereum_peer_count",
+		HeadBlock:  "ethereum_blockchain_height",
+	},
+	ErigonExecutionClientType: {
+		ClientName: "erigon",
+		Peers:      "p2p_peers",
+		HeadBlock:  "chain_head_block",
+		ChainDB:    []string{"db_size"},
+	},
+}
+
+type jsonRPCRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+	ID      int           `json:"id"`
+}
+
+type jsonRPCResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type ethSyncingResult struct {
+	CurrentBlock string `json:"currentBlock"`
+	HighestBlock string `json:"highestBlock"`
+}
+
+func getExecutionData(ctx context.Context, c ClientEndpoint, ts uint64) (*ExecutionData, error) {
+	names, exists := executionClientMetricNames[c.Type]
+	if !exists {
+		return nil, fmt.Errorf("unknown execution-client-type: %v", c.Type)
+	}
+
+	data := &ExecutionData{}
+
+	// CommonData
+	data.Version = specVersion
+	data.Timestamp = ts
+	data.ExporterVersion = exporterVersion
+	data.CommonNetworkData = &CommonNetworkData{Network: network.Name}
+	data.Process = "execution"
+
+	// ProcessData
+	data.ClientName = names.ClientName
+	data.ClientBuild = 0
+	data.SyncEth2FallbackConfigured = false
+	data.SyncEth2FallbackConnected = false
+
+	if c.Address != "" {
+		metrics, err := getMetrics(ctx, c.Address, &c.Auth)
+		if err != nil {
+			return nil, err
+		}
+
+		data.setCPUProcessSeconds(getMetricValueFromFamilyMap(metrics, "process_cpu_seconds_total"))
+		data.MemoryProcessBytes = uint64(getMetricValueFromFamilyMap(metrics, "process_resident_memory_bytes"))
+
+		// ExecutionData
+		for _, name := range names.ChainDB {
+			data.DiskChaindataBytesTotal += uint64(getMetricValueSumFromFamilyMap(metrics, name))
+		}
+		data.NetworkPeersConnected = uint64(getMetricValueFromFamilyMap(metrics, names.Peers))
+		// SyncEth1Synced and SyncEth1HighestBlock are only known via the json-rpc
+		data.SyncEth1CurrentBlock = uint64(getMetricValueFromFamilyMap(metrics, names.HeadBlock))
+	}
+
+	if c.RPCAddress != "" {
+		var clientVersion string
+		err := callJSONRPC(ctx, c.RPCAddress, &c.Auth, "web3_clientVersion", &clientVersion)
+		if err != nil {
+			return nil, err
+		}
+		// eg: Geth/v1.13.5-stable-916d6a44/linux-amd64/go1.21.4
+		if parts := strings.Split(clientVersion, "/"); len(parts) > 1 {
+			data.ClientVersion = parts[1]
+		} else {
+			data.ClientVersion = clientVersion
+		}
+
+		var peerCount string
+		err = callJSONRPC(ctx, c.RPCAddress, &c.Auth, "net_peerCount", &peerCount)
+		if err != nil {
+			return nil, err
+		}
+		data.NetworkPeersConnected, err = parseHexUint64(peerCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing net_peerCount: %w", err)
+		}
+
+		// eth_syncing returns false if the client is synced and an object otherwise
+		var syncing json.RawMessage
+		err = callJSONRPC(ctx, c.RPCAddress, &c.Auth, "eth_syncing", &syncing)
+		if err != nil {
+			return nil, err
+		}
+		if string(syncing) == "false" {
+			data.SyncEth1Synced = true
+			var blockNumber string
+			err = callJSONRPC(ctx, c.RPCAddress, &c.Auth, "eth_blockNumber", &blockNumber)
+			if err != nil {
+				return nil, err
+			}
+			data.SyncEth1CurrentBlock, err = parseHexUint64(blockNumber)
+			if err != nil {
+				return nil, fmt.Errorf("failed parsing eth_blockNumber: %w", err)
+			}
+			data.SyncEth1HighestBlock = data.SyncEth1CurrentBlock
+		} else {
+			s := ethSyncingResult{}
+			err = json.Unmarshal(syncing, &s)
+			if err != nil {
+				return nil, fmt.Errorf("failed decoding eth_syncing: %w", err)
+			}
+			data.SyncEth1Synced = false
+			data.SyncEth1CurrentBlock, err = parseHexUint64(s.CurrentBlock)
+			if err != nil {
+				return nil, fmt.Errorf("failed parsing eth_syncing.currentBlock: %w", err)
+			}
+			data.SyncEth1HighestBlock, err = parseHexUint64(s.HighestBlock)
+			if err != nil {
+				return nil, fmt.Errorf("failed parsing eth_syncing.highestBlock: %w", err)
+			}
+		}
+	}
+
+	return data, nil
+}
+
+func callJSONRPC(ctx context.Context, endpoint string, auth *EndpointAuth, method string, result interface{}) error {
+	reqJSON, err := json.Marshal(&jsonRPCRequest{JSONRPC: "2.0", Method: method, Params: []interface{}{}, ID: 1})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(reqJSON))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	err = auth.apply(req)
+	if err != nil {
+		return err
+	}
+	res, err := scrapeHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed calling %v: %w", method, newScrapeRequestError(endpoint, err))
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("got error-response for %v: %s: %w", method, body, &ScrapeError{URL: endpoint, Reason: ScrapeErrorStatus, StatusCode: res.StatusCode})
+	}
+
+	rpcRes := jsonRPCResponse{}
+	err = json.Unmarshal(body, &rpcRes)
+	if err != nil {
+		return fmt.Errorf("failed decoding response for %v: %w", method, &ScrapeError{URL: endpoint, Reason: ScrapeErrorParse, Err: err})
+	}
+
+	if rpcRes.Error != nil {
+		return fmt.Errorf("got error for %v: %v: %v", method, rpcRes.Error.Code, rpcRes.Error.Message)
+	}
+	return json.Unmarshal(rpcRes.Result, result)
+}
+
+func parseHexUint64(s string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 64)
+}
@@ -0,0 +1,36 @@
+package main
+
+// stripExtendedData removes the extended fields of a record if the server does not support them
+// (see: --server.extended), the collectors always set them so the alert-rules can use them
+func stripExtendedData(d interface{}) {
+	if options.ServerExtended {
+		return
+	}
+	switch d := d.(type) {
+	case *SystemData:
+		d.CommonNetworkData = nil
+		d.SystemCPUData = nil
+		d.SystemNetworkData = nil
+		d.SystemHostData = nil
+		d.SystemClockData = nil
+		d.SystemSampleData = nil
+		d.SystemRateData = nil
+	case *BeaconnodeData:
+		stripExtendedProcessData(&d.ProcessData)
+		d.BeaconnodeSyncData = nil
+		d.BeaconnodeSampleData = nil
+		d.BeaconnodeRateData = nil
+	case *ValidatorData:
+		stripExtendedProcessData(&d.ProcessData)
+		d.ValidatorStatusData = nil
+		d.ValidatorPerformanceData = nil
+	case *ExecutionData:
+		stripExtendedProcessData(&d.ProcessData)
+	}
+}
+
+func stripExtendedProcessData(d *ProcessData) {
+	d.CommonNetworkData = nil
+	d.ProcessCPUData = nil
+	d.ProcessCgroupData = nil
+}
@@ -5,9 +5,12 @@ go 1.16
 require (
 	github.com/StackExchange/wmi v0.0.0-20210224194228-fe8f1750fd46 // indirect
 	github.com/go-ole/go-ole v1.2.5 // indirect
+	github.com/klauspost/compress v1.13.6
 	github.com/prometheus/client_model v0.2.0
 	github.com/prometheus/common v0.29.0
 	github.com/shirou/gopsutil v3.21.5+incompatible
 	github.com/sirupsen/logrus v1.6.0
 	github.com/tklauser/go-sysconf v0.3.6 // indirect
+	golang.org/x/sys v0.0.0-20210603081109-ebe580a85c40
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	promModel "github.com/prometheus/client_model/go"
+	promExpfmt "github.com/prometheus/common/expfmt"
+	"github.com/sirupsen/logrus"
+)
+
+type ScrapeErrorReason string
+
+const (
+	ScrapeErrorConnectionRefused ScrapeErrorReason = "connection_refused"
+	ScrapeErrorTimeout           ScrapeErrorReason = "timeout"
+	ScrapeErrorStatus            ScrapeErrorReason = "status"
+	ScrapeErrorContentType       ScrapeErrorReason = "content_type"
+	ScrapeErrorParse             ScrapeErrorReason = "parse"
+	ScrapeErrorOther             ScrapeErrorReason = "other"
+)
+
+// ScrapeError describes why scraping an endpoint failed
+type ScrapeError struct {
+	URL         string
+	Reason      ScrapeErrorReason
+	StatusCode  int
+	ContentType string
+	Err         error
+}
+
+func (e *ScrapeError) Error() string {
+	switch e.Reason {
+	case ScrapeErrorConnectionRefused:
+		return fmt.Sprintf("connection refused by %v, is the client running with metrics enabled: %v", e.URL, e.Err)
+	case ScrapeErrorTimeout:
+		return fmt.Sprintf("timeout scraping %v: %v", e.URL, e.Err)
+	case ScrapeErrorStatus:
+		return fmt.Sprintf("got status %v from %v, is the path of the address correct and are the credentials valid", e.StatusCode, e.URL)
+	case ScrapeErrorContentType:
+		return fmt.Sprintf("got unexpected content-type %q from %v, does the address point to a metrics-endpoint", e.ContentType, e.URL)
+	case ScrapeErrorParse:
+		return fmt.Sprintf("failed parsing metrics from %v: %v", e.URL, e.Err)
+	}
+	return fmt.Sprintf("failed scraping %v: %v", e.URL, e.Err)
+}
+
+func (e *ScrapeError) Unwrap() error {
+	return e.Err
+}
+
+// newScrapeRequestError classifies an error returned by the http-client
+func newScrapeRequestError(url string, err error) *ScrapeError {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return &ScrapeError{URL: url, Reason: ScrapeErrorConnectionRefused, Err: err}
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return &ScrapeError{URL: url, Reason: ScrapeErrorTimeout, Err: err}
+	}
+	return &ScrapeError{URL: url, Reason: ScrapeErrorOther, Err: err}
+}
+
+func scrapeErrorReason(err error) ScrapeErrorReason {
+	var scrapeErr *ScrapeError
+	if errors.As(err, &scrapeErr) {
+		return scrapeErr.Reason
+	}
+	return ScrapeErrorOther
+}
+
+type endpointHealth struct {
+	Endpoint   string            `json:"endpoint"`
+	Type       ClientType        `json:"type"`
+	Healthy    bool              `json:"healthy"`
+	LastScrape time.Time         `json:"last_scrape"`
+	Reason     ScrapeErrorReason `json:"reason,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	scrapes    uint64
+	errors     map[ScrapeErrorReason]uint64
+}
+
+// health keeps track of the scrapes and pushes, it is exposed via /health and /metrics if --health.address is set
+var health = struct {
+	sync.Mutex
+	endpoints  map[string]*endpointHealth
+	pushes     uint64
+	pushErrors uint64
+	lastPush   time.Time
+	lastPushOK bool
+}{endpoints: map[string]*endpointHealth{}}
+
+func recordScrape(name string, clientType ClientType, err error) {
+	health.Lock()
+	defer health.Unlock()
+	h, exists := health.endpoints[name]
+	if !exists {
+		h = &endpointHealth{Endpoint: name, Type: clientType, errors: map[ScrapeErrorReason]uint64{}}
+		health.endpoints[name] = h
+	}
+	h.scrapes++
+	h.LastScrape = time.Now()
+	h.Healthy = err == nil
+	h.Reason = ""
+	h.Error = ""
+	if err != nil {
+		h.Reason = scrapeErrorReason(err)
+		h.Error = err.Error()
+		h.errors[h.Reason]++
+	}
+}
+
+// endpointsHealthy returns whether the last scrape of each endpoint succeeded
+func endpointsHealthy() map[string]bool {
+	health.Lock()
+	defer health.Unlock()
+	healthy := map[string]bool{}
+	for name, h := range health.endpoints {
+		healthy[name] = h.Healthy
+	}
+	return healthy
+}
+
+func recordPush(err error) {
+	health.Lock()
+	defer health.Unlock()
+	health.pushes++
+	health.lastPush = time.Now()
+	health.lastPushOK = err == nil
+	if err != nil {
+		health.pushErrors++
+	}
+}
+
+func serveHealth(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", healthHandler)
+	mux.HandleFunc("/metrics", selfMetricsHandler)
+	logrus.WithFields(logrus.Fields{"address": addr}).Info("serving /health and /metrics")
+	err := http.ListenAndServe(addr, mux)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed serving health")
+	}
+}
+
+// healthHandler responds with 503 if the last scrape of any endpoint or the last push failed
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	health.Lock()
+	res := struct {
+		Healthy    bool              `json:"healthy"`
+		LastPush   time.Time         `json:"last_push"`
+		LastPushOK bool              `json:"last_push_ok"`
+		Endpoints  []*endpointHealth `json:"endpoints"`
+	}{Healthy: true, LastPush: health.lastPush, LastPushOK: health.lastPushOK, Endpoints: []*endpointHealth{}}
+	if !health.lastPush.IsZero() && !health.lastPushOK {
+		res.Healthy = false
+	}
+	for _, h := range health.endpoints {
+		c := *h
+		res.Endpoints = append(res.Endpoints, &c)
+		if !h.Healthy {
+			res.Healthy = false
+		}
+	}
+	health.Unlock()
+	sort.Slice(res.Endpoints, func(i, j int) bool { return res.Endpoints[i].Endpoint < res.Endpoints[j].Endpoint })
+
+	w.Header().Set("Content-Type", "application/json")
+	if !res.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	err := json.NewEncoder(w).Encode(res)
+	if err != nil {
+		logrus.WithError(err).Error("failed encoding health")
+	}
+}
+
+func selfMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	families := append(selfMetricFamilies(), rateMetricFamilies()...)
+	w.Header().Set("Content-Type", string(promExpfmt.FmtText))
+	for _, mf := range families {
+		_, err := promExpfmt.MetricFamilyToText(w, mf)
+		if err != nil {
+			logrus.WithError(err).Error("failed encoding self-metrics")
+			return
+		}
+	}
+}
+
+func selfMetricFamilies() []*promModel.MetricFamily {
+	health.Lock()
+	defer health.Unlock()
+
+	counter := promModel.MetricType_COUNTER
+	gauge := promModel.MetricType_GAUGE
+	newFamily := func(name, help string, typ promModel.MetricType) *promModel.MetricFamily {
+		return &promModel.MetricFamily{Name: &name, Help: &help, Type: &typ}
+	}
+	newMetric := func(value float64, typ promModel.MetricType, labels ...string) *promModel.Metric {
+		m := &promModel.Metric{}
+		for i := 0; i+1 < len(labels); i += 2 {
+			name, value := labels[i], labels[i+1]
+			m.Label = append(m.Label, &promModel.LabelPair{Name: &name, Value: &value})
+		}
+		if typ == promModel.MetricType_COUNTER {
+			m.Counter = &promModel.Counter{Value: &value}
+		} else {
+			m.Gauge = &promModel.Gauge{Value: &value}
+		}
+		return m
+	}
+
+	up := newFamily("eth2_client_metrics_exporter_scrape_up", "Whether the last scrape of the endpoint succeeded.", gauge)
+	scrapes := newFamily("eth2_client_metrics_exporter_scrapes_total", "Number of scrapes of the endpoint.", counter)
+	scrapeErrors := newFamily("eth2_client_metrics_exporter_scrape_errors_total", "Number of failed scrapes of the endpoint by reason.", counter)
+	names := []string{}
+	for name := range health.endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		h := health.endpoints[name]
+		upValue := float64(0)
+		if h.Healthy {
+			upValue = 1
+		}
+		up.Metric = append(up.Metric, newMetric(upValue, gauge, "endpoint", name, "type", string(h.Type)))
+		scrapes.Metric = append(scrapes.Metric, newMetric(float64(h.scrapes), counter, "endpoint", name, "type", string(h.Type)))
+		for _, reason := range []ScrapeErrorReason{ScrapeErrorConnectionRefused, ScrapeErrorTimeout, ScrapeErrorStatus, ScrapeErrorContentType, ScrapeErrorParse, ScrapeErrorOther} {
+			scrapeErrors.Metric = append(scrapeErrors.Metric, newMetric(float64(h.errors[reason]), counter, "endpoint", name, "type", string(h.Type), "reason", string(reason)))
+		}
+	}
+
+	pushes := newFamily("eth2_client_metrics_exporter_pushes_total", "Number of pushes to the server.", counter)
+	pushes.Metric = append(pushes.Metric, newMetric(float64(health.pushes), counter))
+	pushErrors := newFamily("eth2_client_metrics_exporter_push_errors_total", "Number of failed pushes to the server.", counter)
+	pushErrors.Metric = append(pushErrors.Metric, newMetric(float64(health.pushErrors), counter))
+
+	families := []*promModel.MetricFamily{pushes, pushErrors}
+	if len(names) > 0 {
+		families = append(families, up, scrapes, scrapeErrors)
+	}
+	return families
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/host"
+	"github.com/shirou/gopsutil/load"
+	"github.com/shirou/gopsutil/mem"
+	"github.com/sirupsen/logrus"
+)
+
+// tcpStates maps the states of /proc/net/tcp, see: include/net/tcp_states.h
+var tcpStates = map[string]string{
+	"01": "established",
+	"02": "syn_sent",
+	"03": "syn_recv",
+	"04": "fin_wait1",
+	"05": "fin_wait2",
+	"06": "time_wait",
+	"07": "close",
+	"08": "close_wait",
+	"09": "last_ack",
+	"0A": "listen",
+	"0B": "closing",
+}
+
+// cpuSensorKeys are substrings of the keys of the temperature-sensors of cpus (hwmon) and socs (thermal-zones)
+var cpuSensorKeys = []string{"coretemp", "k10temp", "zenpower", "x86_pkg_temp", "cpu", "soc"}
+
+func hostProc(name string) string {
+	proc := os.Getenv("HOST_PROC")
+	if proc == "" {
+		return filepath.Join("/proc", name)
+	}
+	return filepath.Join(proc, name)
+}
+
+// getSystemHostData collects the extended host-metrics, metrics which are not available on the
+// system are left empty
+func getSystemHostData() *SystemHostData {
+	data := &SystemHostData{}
+
+	avg, err := load.Avg()
+	if err != nil {
+		logrus.WithError(err).Debug("failed getting load averages")
+	} else {
+		data.CPUNodeLoad1 = avg.Load1
+		data.CPUNodeLoad5 = avg.Load5
+		data.CPUNodeLoad15 = avg.Load15
+	}
+
+	memStat, err := mem.VirtualMemory()
+	if err != nil {
+		logrus.WithError(err).Debug("failed getting memory stats")
+	} else {
+		data.MemoryNodeBytesAvailable = memStat.Available
+	}
+
+	swapStat, err := mem.SwapMemory()
+	if err != nil {
+		logrus.WithError(err).Debug("failed getting swap stats")
+	} else {
+		data.MemoryNodeSwapBytesTotal = swapStat.Total
+		data.MemoryNodeSwapBytesUsed = swapStat.Used
+	}
+
+	data.MiscNodeCPUTemperatureCelsius, err = getCPUTemperature()
+	if err != nil {
+		logrus.WithError(err).Debug("failed getting cpu temperature")
+	}
+
+	data.MiscNodeFileDescriptorsOpen, data.MiscNodeFileDescriptorsMax, err = getFileDescriptors()
+	if err != nil {
+		logrus.WithError(err).Debug("failed getting file descriptors")
+	}
+
+	data.NetworkNodeTCPConnections, err = getTCPConnectionStates()
+	if err != nil {
+		logrus.WithError(err).Debug("failed getting tcp connection states")
+	}
+
+	return data
+}
+
+// getCPUTemperature returns the highest temperature of the cpu-sensors, or of all sensors if
+// none of them is known to belong to the cpu
+func getCPUTemperature() (float64, error) {
+	temperatures, err := host.SensorsTemperatures()
+	if len(temperatures) == 0 {
+		if err == nil {
+			err = fmt.Errorf("no temperature sensors found")
+		}
+		return 0, err
+	}
+	max, cpuMax := 0.0, 0.0
+	for _, t := range temperatures {
+		key := strings.ToLower(t.SensorKey)
+		// hwmon also reports the thresholds of the sensors
+		if strings.HasSuffix(key, "max") || strings.HasSuffix(key, "crit") || strings.HasSuffix(key, "min") || strings.HasSuffix(key, "alarm") {
+			continue
+		}
+		if t.Temperature > max {
+			max = t.Temperature
+		}
+		for _, k := range cpuSensorKeys {
+			if strings.Contains(key, k) && t.Temperature > cpuMax {
+				cpuMax = t.Temperature
+			}
+		}
+	}
+	if cpuMax > 0 {
+		return cpuMax, nil
+	}
+	return max, nil
+}
+
+// getFileDescriptors returns the open and the maximum file-descriptors of the system
+func getFileDescriptors() (uint64, uint64, error) {
+	b, err := ioutil.ReadFile(hostProc("sys/fs/file-nr"))
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) != 3 {
+		return 0, 0, fmt.Errorf("unexpected format of file-nr: %s", b)
+	}
+	allocated, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	unused, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	max, err := strconv.ParseUint(fields[2], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return allocated - unused, max, nil
+}
+
+// getTCPConnectionStates counts the tcp-connections (ipv4 and ipv6) of the host by state
+func getTCPConnectionStates() (map[string]uint64, error) {
+	// the network-namespace of init is the one of the host if the exporter runs in a container
+	dir := "net"
+	if os.Getenv("HOST_PROC") != "" {
+		dir = "1/net"
+	}
+	states := map[string]uint64{}
+	for _, name := range []string{"tcp", "tcp6"} {
+		f, err := os.Open(hostProc(filepath.Join(dir, name)))
+		if os.IsNotExist(err) && name == "tcp6" {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Scan() // header
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 4 {
+				continue
+			}
+			if state, exists := tcpStates[fields[3]]; exists {
+				states[state]++
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return states, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type httpClientOptions struct {
+	Timeout       time.Duration
+	TLSCert       string // path to client-certificate (PEM) for mTLS
+	TLSKey        string // path to client-key (PEM) for mTLS
+	TLSCA         string // path to CA-bundle (PEM) to verify the server-certificate with
+	TLSMinVersion string // one of: 1.0, 1.1, 1.2, 1.3
+	Proxy         string // http://, https:// or socks5:// proxy-url, proxy from environment if empty
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func newHTTPClient(o httpClientOptions) (*http.Client, error) {
+	tlsConfig := &tls.Config{}
+
+	minVersion, exists := tlsVersions[o.TLSMinVersion]
+	if !exists {
+		return nil, fmt.Errorf("invalid tls min-version: %v", o.TLSMinVersion)
+	}
+	tlsConfig.MinVersion = minVersion
+
+	if o.TLSCert != "" || o.TLSKey != "" {
+		if o.TLSCert == "" || o.TLSKey == "" {
+			return nil, fmt.Errorf("tls cert and key are both required for client-authentication")
+		}
+		cert, err := tls.LoadX509KeyPair(o.TLSCert, o.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed loading tls client-certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if o.TLSCA != "" {
+		caPEM, err := ioutil.ReadFile(o.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading tls ca-bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in tls ca-bundle: %v", o.TLSCA)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	if o.Proxy != "" {
+		proxyURL, err := url.Parse(o.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy-url: %w", err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("invalid proxy-url: unsupported scheme: %v", proxyURL.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return &http.Client{
+		Timeout:   o.Timeout,
+		Transport: transport,
+	}, nil
+}
+
+// EndpointAuth is applied to the requests to a scraped endpoint
+type EndpointAuth struct {
+	BasicAuth       string // user:password
+	BearerTokenFile string // read on every request to pick up rotated tokens
+	Headers         headerFlags
+}
+
+func (a *EndpointAuth) apply(req *http.Request) error {
+	for _, h := range a.Headers {
+		parts := strings.SplitN(h, ":", 2)
+		req.Header.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+	if a.BasicAuth != "" {
+		parts := strings.SplitN(a.BasicAuth, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid basic-auth credentials, expected user:password")
+		}
+		req.SetBasicAuth(parts[0], parts[1])
+	}
+	if a.BearerTokenFile != "" {
+		token, err := ioutil.ReadFile(a.BearerTokenFile)
+		if err != nil {
+			return fmt.Errorf("failed reading bearer-token-file: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	return nil
+}
+
+// headerFlags implements flag.Value to collect repeated header-flags
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(value string) error {
+	if !strings.Contains(value, ":") {
+		return fmt.Errorf("invalid header, expected Name: value")
+	}
+	*h = append(*h, value)
+	return nil
+}
@@ -2,14 +2,19 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"math"
+	"mime"
 	"net/http"
+	"os"
+	"os/signal"
 	"runtime"
 	"sync"
+	"syscall"
 	"time"
 
 	promModel "github.com/prometheus/client_model/go"
@@ -18,7 +23,6 @@ import (
 	"github.com/shirou/gopsutil/disk"
 	"github.com/shirou/gopsutil/host"
 	"github.com/shirou/gopsutil/mem"
-	"github.com/shirou/gopsutil/net"
 	"github.com/sirupsen/logrus"
 )
 
@@ -30,15 +34,65 @@ var (
 )
 
 var options = struct {
-	ServerAddress     string
-	ServerTimeout     time.Duration
-	BeaconnodeType    string
-	BeaconnodeAddress string
-	ValidatorType     string
-	ValidatorAddress  string
-	Interval          time.Duration
-	Partition         string
-	Debug             bool
+	ServerAddress         string
+	ServerTimeout         time.Duration
+	ScrapeTimeout         time.Duration
+	CycleTimeout          time.Duration
+	BeaconnodeTimeout     time.Duration
+	ValidatorTimeout      time.Duration
+	ExecutionTimeout      time.Duration
+	BeaconnodeCgroup      string
+	ValidatorCgroup       string
+	ExecutionCgroup       string
+	CgroupDockerSocket    string
+	ServerExtended        bool
+	ServerCompression     string
+	ServerTLSCert         string
+	ServerTLSKey          string
+	ServerTLSCA           string
+	ServerTLSMinVersion   string
+	ServerProxy           string
+	ScrapeTLSCert         string
+	ScrapeTLSKey          string
+	ScrapeTLSCA           string
+	ScrapeTLSMinVersion   string
+	ScrapeProxy           string
+	BeaconnodeAuth        EndpointAuth
+	ValidatorAuth         EndpointAuth
+	ExecutionAuth         EndpointAuth
+	BeaconnodeType        string
+	BeaconnodeAddress     string
+	BeaconnodeAPI         string
+	ValidatorType         string
+	ValidatorAddress      string
+	ValidatorAPI          string
+	ValidatorAPITokenFile string
+	ExecutionType         string
+	ExecutionAddress      string
+	ExecutionRPC          string
+	Interval              time.Duration
+	SampleInterval        time.Duration
+	Partition             string
+	NetInterfaces         string
+	Network               string
+	NetworkGenesisTime    uint64
+	NetworkSecondsPerSlot uint64
+	NetworkSlotsPerEpoch  uint64
+	SyncTolerance         uint64
+	SyncStallIntervals    int
+	AlertsConfig          string
+	HealthAddress         string
+	ScheduleMode          string
+	ScheduleJitter        time.Duration
+	BufferSize            int
+	BufferPolicy          string
+	BufferSpillDir        string
+	PushSenders           int
+	ShutdownTimeout       time.Duration
+	ShutdownSpoolFile     string
+	Discover              bool
+	DiscoverDocker        string
+	Debug                 bool
 }{}
 
 type ClientType string
@@ -47,11 +101,27 @@ const (
 	PrysmBeaconnodeMetricsClientType  ClientType = "prysm-beaconnode-metrics"
 	PrysmValidatorMetricsClientType   ClientType = "prysm-validator-metrics"
 	NimbusBeaconnodeMetricsClientType ClientType = "nimbus-beaconnode-metrics"
+	GethExecutionClientType           ClientType = "geth-execution"
+	NethermindExecutionClientType     ClientType = "nethermind-execution"
+	BesuExecutionClientType           ClientType = "besu-execution"
+	ErigonExecutionClientType         ClientType = "erigon-execution"
+	ValidatorAPIClientType            ClientType = "validator-api"
+	AutoBeaconnodeClientType          ClientType = "auto-beaconnode"
+	AutoValidatorClientType           ClientType = "auto-validator"
 )
 
 type ClientEndpoint struct {
-	Type    ClientType
-	Address string
+	Name       string // one of: beaconnode, validator, execution
+	Type       ClientType
+	Address    string
+	RPCAddress string // json-rpc address, only used by execution clients
+	// keymanager-api address, only used by validators
+	KeymanagerAddress string
+	// beacon-api address, used by validators and to detect the client of beaconnodes
+	BeaconAPIAddress string
+	Auth             EndpointAuth // used for the metrics- and json-rpc-endpoints
+	Timeout          time.Duration
+	Cgroup           string // cgroup-path or docker:<container-name> to read the container-stats from
 }
 
 type ServerResponse struct {
@@ -61,7 +131,9 @@ type ServerResponse struct {
 
 var clientEndpoints = []ClientEndpoint{}
 
-var httpClient *http.Client
+// serverHTTPClient is used to push data to the server, scrapeHTTPClient to scrape the clients
+var serverHTTPClient *http.Client
+var scrapeHTTPClient *http.Client
 
 var specVersion = int64(2)
 var exporterVersion = ""
@@ -69,13 +141,68 @@ var exporterVersion = ""
 func main() {
 	flag.BoolVar(&options.Debug, "debug", false, "enable debugging")
 	flag.DurationVar(&options.Interval, "interval", time.Second*62, "interval of sending metrics to server")
+	flag.DurationVar(&options.SampleInterval, "sample.interval", 0, "interval of sampling cpu, memory, network and peers between the collections, min, max and avg of the samples are sent as extended fields (see: server.extended), disabled if 0")
 	flag.StringVar(&options.ServerAddress, "server.address", "", "address of server to push metrics to")
 	flag.DurationVar(&options.ServerTimeout, "server.timeout", time.Second*10, "timeout for sending data to the server")
+	flag.BoolVar(&options.ServerExtended, "server.extended", false, "send extended fields which are not part of the spec, only enable this if the server supports them")
+	flag.StringVar(&options.ServerCompression, "server.compression", "none", "compression of the data sent to the server (none, gzip, zstd, auto), auto negotiates the compression with the server")
+	flag.StringVar(&options.ServerTLSCert, "server.tls.cert", "", "path to client-certificate (PEM) for mTLS with the server")
+	flag.StringVar(&options.ServerTLSKey, "server.tls.key", "", "path to client-key (PEM) for mTLS with the server")
+	flag.StringVar(&options.ServerTLSCA, "server.tls.ca", "", "path to CA-bundle (PEM) to verify the certificate of the server, system CAs if empty string")
+	flag.StringVar(&options.ServerTLSMinVersion, "server.tls.min-version", "1.2", "minimum TLS version for the connection to the server (1.0, 1.1, 1.2, 1.3)")
+	flag.StringVar(&options.ServerProxy, "server.proxy", "", "proxy for the connection to the server (http://, https:// or socks5://), HTTP_PROXY/HTTPS_PROXY from environment if empty string")
+	flag.StringVar(&options.ScrapeTLSCert, "scrape.tls.cert", "", "path to client-certificate (PEM) for mTLS with the scraped endpoints")
+	flag.StringVar(&options.ScrapeTLSKey, "scrape.tls.key", "", "path to client-key (PEM) for mTLS with the scraped endpoints")
+	flag.StringVar(&options.ScrapeTLSCA, "scrape.tls.ca", "", "path to CA-bundle (PEM) to verify the certificates of the scraped endpoints, system CAs if empty string")
+	flag.StringVar(&options.ScrapeTLSMinVersion, "scrape.tls.min-version", "1.2", "minimum TLS version for the connections to the scraped endpoints (1.0, 1.1, 1.2, 1.3)")
+	flag.StringVar(&options.ScrapeProxy, "scrape.proxy", "", "proxy for the connections to the scraped endpoints (http://, https:// or socks5://), HTTP_PROXY/HTTPS_PROXY from environment if empty string")
+	for _, a := range []struct {
+		prefix string
+		auth   *EndpointAuth
+	}{{"beaconnode", &options.BeaconnodeAuth}, {"validator", &options.ValidatorAuth}, {"execution", &options.ExecutionAuth}} {
+		flag.StringVar(&a.auth.BasicAuth, a.prefix+".auth.basic", "", "basic-auth credentials (user:password) for the "+a.prefix+"-endpoints")
+		flag.StringVar(&a.auth.BearerTokenFile, a.prefix+".auth.bearer-file", "", "path to file containing the bearer-token for the "+a.prefix+"-endpoints")
+		flag.Var(&a.auth.Headers, a.prefix+".header", "custom header (Name: value) sent to the "+a.prefix+"-endpoints, can be repeated")
+	}
+	flag.DurationVar(&options.ScrapeTimeout, "scrape.timeout", time.Second*10, "default timeout for scraping an endpoint")
+	flag.DurationVar(&options.CycleTimeout, "cycle.timeout", time.Second*30, "deadline for collecting data from all endpoints, the data of endpoints that miss it is not sent")
+	flag.DurationVar(&options.BeaconnodeTimeout, "beaconnode.timeout", 0, "timeout for scraping the beaconnode, scrape.timeout if 0")
+	flag.DurationVar(&options.ValidatorTimeout, "validator.timeout", 0, "timeout for scraping the validator, scrape.timeout if 0")
+	flag.DurationVar(&options.ExecutionTimeout, "execution.timeout", 0, "timeout for scraping the execution client, scrape.timeout if 0")
+	flag.StringVar(&options.BeaconnodeCgroup, "beaconnode.cgroup", "", "cgroup-path (eg: /system.slice/beacon-chain.service) or docker:<container-name> of the beaconnode to send its container-stats as extended fields, disabled if empty string")
+	flag.StringVar(&options.ValidatorCgroup, "validator.cgroup", "", "cgroup-path (eg: /system.slice/validator.service) or docker:<container-name> of the validator to send its container-stats as extended fields, disabled if empty string")
+	flag.StringVar(&options.ExecutionCgroup, "execution.cgroup", "", "cgroup-path (eg: /system.slice/geth.service) or docker:<container-name> of the execution client to send its container-stats as extended fields, disabled if empty string")
+	flag.StringVar(&options.CgroupDockerSocket, "cgroup.docker-socket", "/var/run/docker.sock", "path to docker-socket to resolve the cgroups given as docker:<container-name>")
+	flag.StringVar(&options.NetInterfaces, "system.net-interfaces", "", "comma-separated globs of the network interfaces to count the traffic of, globs prefixed with ! exclude interfaces (eg: eth*,!eth1), all but loopback and virtual interfaces if empty string")
 	flag.StringVar(&options.Partition, "system.partition", "/", "mountpoint of partition which will be tracked for usage, if empty-string the highest usage of any partition will be recorded")
-	flag.StringVar(&options.BeaconnodeType, "beaconnode.type", "prysm", "endpoint to scrape metrics from")
+	flag.StringVar(&options.BeaconnodeType, "beaconnode.type", "auto", "type of beaconnode (auto, prysm, nimbus), auto detects the client by its metrics or beaconnode.api")
 	flag.StringVar(&options.BeaconnodeAddress, "beaconnode.address", "", "address of beaconnode-endpoint to scrape metrics from (eg: http://localhost:8080/metrics), disabled if empty string")
-	flag.StringVar(&options.ValidatorType, "validator.type", "prysm", "endpoint to scrape metrics from")
+	flag.StringVar(&options.BeaconnodeAPI, "beaconnode.api", "", "address of beacon-api to look up validator statuses (eg: http://localhost:5052), required by validator.api")
+	flag.StringVar(&options.ValidatorType, "validator.type", "auto", "type of validator (auto, prysm), auto detects the client by its metrics, with only validator.api any client can be used (prysm, lighthouse, teku, nimbus, lodestar) and auto detects it via beaconnode.api")
 	flag.StringVar(&options.ValidatorAddress, "validator.address", "", "address of validator-endpoint to scrape metrics from (eg: http://localhost:8081/metrics), disabled if emtpy string")
+	flag.StringVar(&options.ValidatorAPI, "validator.api", "", "address of keymanager-api of the validator to count validators by their status for any client (eg: http://localhost:7500), disabled if empty string")
+	flag.StringVar(&options.ValidatorAPITokenFile, "validator.api-token-file", "", "path to file containing the bearer-token for the keymanager-api")
+	flag.StringVar(&options.ExecutionType, "execution.type", "geth", "type of execution client (geth, nethermind, besu, erigon)")
+	flag.StringVar(&options.ExecutionAddress, "execution.address", "", "address of execution-client-metrics-endpoint to scrape metrics from (eg: http://localhost:6060/debug/metrics/prometheus), disabled if empty string")
+	flag.StringVar(&options.ExecutionRPC, "execution.rpc", "", "address of execution-client-json-rpc to query sync status from (eg: http://localhost:8545), disabled if empty string")
+	flag.StringVar(&options.Network, "network", "mainnet", "network of the nodes (mainnet, holesky, sepolia, gnosis, custom), used to compute the current slot and epoch")
+	flag.Uint64Var(&options.NetworkGenesisTime, "network.genesis-time", 0, "unix timestamp of the genesis of the network, overrides the value of the network-preset if not 0 (required for custom network)")
+	flag.Uint64Var(&options.NetworkSecondsPerSlot, "network.seconds-per-slot", 0, "seconds per slot of the network, overrides the value of the network-preset if not 0 (required for custom network)")
+	flag.Uint64Var(&options.NetworkSlotsPerEpoch, "network.slots-per-epoch", 0, "slots per epoch of the network, overrides the value of the network-preset if not 0")
+	flag.Uint64Var(&options.SyncTolerance, "sync.tolerance", 2, "number of slots the head of the beaconnode may be behind the current slot to be considered synced")
+	flag.IntVar(&options.SyncStallIntervals, "sync.stall-intervals", 3, "number of intervals the head of the beaconnode may not advance before it is considered stalled (and not synced), disabled if 0")
+	flag.StringVar(&options.AlertsConfig, "alerts.config", "", "path to yaml-file with alert-rules and notification-sinks, disabled if empty string")
+	flag.StringVar(&options.ScheduleMode, "schedule.mode", "interval", "when to collect data: interval (every interval since the start), wall-clock (at multiples of interval since the unix epoch), slot (at slot boundaries, interval is rounded up to whole slots)")
+	flag.DurationVar(&options.ScheduleJitter, "schedule.jitter", 0, "random offset of up to this duration added to the schedule to spread the load on the server")
+	flag.IntVar(&options.BufferSize, "buffer.size", 100, "number of collected batches buffered in memory until they are sent")
+	flag.StringVar(&options.BufferPolicy, "buffer.policy", "drop-oldest", "what happens if the buffer is full: drop-oldest (drop the oldest batch), block (pause collecting), spill (write new batches to buffer.spill-dir)")
+	flag.StringVar(&options.BufferSpillDir, "buffer.spill-dir", "", "directory to spill batches to with buffer.policy=spill, spilled batches are sent after a restart too")
+	flag.IntVar(&options.PushSenders, "push.senders", 1, "number of concurrent pushes to the server")
+	flag.DurationVar(&options.ShutdownTimeout, "shutdown.timeout", time.Second*5, "timeout for the last push of the pending data on SIGINT or SIGTERM")
+	flag.StringVar(&options.ShutdownSpoolFile, "shutdown.spool-file", "", "file to persist the pending data to if the last push on shutdown fails, it is pushed on the next start, disabled if empty string")
+	flag.StringVar(&options.HealthAddress, "health.address", "", "address to serve /health and the self-metrics of the exporter on /metrics (eg: :9101), disabled if empty string")
+	flag.BoolVar(&options.Discover, "discover", false, "discover the metrics-endpoints of beaconnode and validator by probing well-known ports on localhost, explicitly set addresses take precedence")
+	flag.StringVar(&options.DiscoverDocker, "discover.docker", "", "path to docker-socket to additionally probe the containers of the docker-host (eg: /var/run/docker.sock), disabled if empty string")
 	versionFlag := flag.Bool("version", false, "show version and exit")
 	flag.Parse()
 
@@ -92,9 +219,21 @@ func main() {
 		logrus.Fatal("Server address not provided.")
 	}
 
+	switch options.ServerCompression {
+	case "none", "gzip", "zstd", "auto":
+	default:
+		logrus.Fatal("invalid server.compression")
+	}
+
+	if options.Discover {
+		discoverEndpoints(options.DiscoverDocker)
+	}
+
 	if options.BeaconnodeAddress != "" {
 		var clientType ClientType
 		switch options.BeaconnodeType {
+		case "auto":
+			clientType = AutoBeaconnodeClientType
 		case "prysm":
 			clientType = PrysmBeaconnodeMetricsClientType
 		case "nimbus":
@@ -103,33 +242,151 @@ func main() {
 			logrus.Fatal("invalid beaconnode.type")
 		}
 		clientEndpoints = append(clientEndpoints, ClientEndpoint{
-			Type:    clientType,
-			Address: options.BeaconnodeAddress,
+			Name:             "beaconnode",
+			Type:             clientType,
+			Address:          options.BeaconnodeAddress,
+			BeaconAPIAddress: options.BeaconnodeAPI,
+			Auth:             options.BeaconnodeAuth,
+			Timeout:          endpointTimeout(options.BeaconnodeTimeout),
+			Cgroup:           options.BeaconnodeCgroup,
 		})
 	}
 
-	if options.ValidatorAddress != "" {
+	if options.ValidatorAPI != "" && options.BeaconnodeAPI == "" {
+		logrus.Fatal("validator.api requires beaconnode.api")
+	}
+
+	if options.ValidatorAddress != "" || options.ValidatorAPI != "" {
 		var clientType ClientType
-		switch options.ValidatorType {
-		case "prysm":
+		switch {
+		case options.ValidatorAddress == "":
+			// the keymanager-api works for any client, validator.type is only used as client_name
+			if options.ValidatorType != "auto" && !validatorAPIClientNames[options.ValidatorType] {
+				logrus.Fatal("invalid validator.type")
+			}
+			clientType = ValidatorAPIClientType
+		case options.ValidatorType == "auto":
+			clientType = AutoValidatorClientType
+		case options.ValidatorType == "prysm":
 			clientType = PrysmValidatorMetricsClientType
 		default:
 			logrus.Fatal("invalid validator.type")
 		}
 		clientEndpoints = append(clientEndpoints, ClientEndpoint{
-			Type:    clientType,
-			Address: options.ValidatorAddress,
+			Name:              "validator",
+			Type:              clientType,
+			Address:           options.ValidatorAddress,
+			KeymanagerAddress: options.ValidatorAPI,
+			BeaconAPIAddress:  options.BeaconnodeAPI,
+			Auth:              options.ValidatorAuth,
+			Timeout:           endpointTimeout(options.ValidatorTimeout),
+			Cgroup:            options.ValidatorCgroup,
 		})
 	}
 
-	if options.BeaconnodeAddress == "" && options.ValidatorAddress == "" {
-		logrus.Fatal("Neither beacon node nor validator address provided.")
+	if (options.ExecutionAddress != "" || options.ExecutionRPC != "") && !options.ServerExtended {
+		logrus.Fatal("execution.address and execution.rpc require server.extended, the execution client is not part of the spec")
+	}
+
+	if options.ExecutionAddress != "" || options.ExecutionRPC != "" {
+		var clientType ClientType
+		switch options.ExecutionType {
+		case "geth":
+			clientType = GethExecutionClientType
+		case "nethermind":
+			clientType = NethermindExecutionClientType
+		case "besu":
+			clientType = BesuExecutionClientType
+		case "erigon":
+			clientType = ErigonExecutionClientType
+		default:
+			logrus.Fatal("invalid execution.type")
+		}
+		clientEndpoints = append(clientEndpoints, ClientEndpoint{
+			Name:       "execution",
+			Type:       clientType,
+			Address:    options.ExecutionAddress,
+			RPCAddress: options.ExecutionRPC,
+			Auth:       options.ExecutionAuth,
+			Timeout:    endpointTimeout(options.ExecutionTimeout),
+			Cgroup:     options.ExecutionCgroup,
+		})
+	}
+
+	if options.BeaconnodeAddress == "" && options.ValidatorAddress == "" && options.ValidatorAPI == "" && options.ExecutionAddress == "" && options.ExecutionRPC == "" {
+		logrus.Fatal("Neither beacon node, validator nor execution client address provided.")
+	}
+
+	var err error
+	netInterfaces, err = newNetInterfaceFilter(options.NetInterfaces)
+	if err != nil {
+		logrus.WithError(err).Fatal("invalid system.net-interfaces")
 	}
 
 	exporterVersion = fmt.Sprintf("beaconcha.in@%v", GitCommit)
 
-	httpClient = &http.Client{
-		Timeout: options.ServerTimeout,
+	serverHTTPClient, err = newHTTPClient(httpClientOptions{
+		Timeout:       options.ServerTimeout,
+		TLSCert:       options.ServerTLSCert,
+		TLSKey:        options.ServerTLSKey,
+		TLSCA:         options.ServerTLSCA,
+		TLSMinVersion: options.ServerTLSMinVersion,
+		Proxy:         options.ServerProxy,
+	})
+	if err != nil {
+		logrus.WithError(err).Fatal("invalid server.tls or server.proxy")
+	}
+
+	// scrapes are bound by the per-endpoint-timeouts via their context
+	scrapeHTTPClient, err = newHTTPClient(httpClientOptions{
+		TLSCert:       options.ScrapeTLSCert,
+		TLSKey:        options.ScrapeTLSKey,
+		TLSCA:         options.ScrapeTLSCA,
+		TLSMinVersion: options.ScrapeTLSMinVersion,
+		Proxy:         options.ScrapeProxy,
+	})
+	if err != nil {
+		logrus.WithError(err).Fatal("invalid scrape.tls or scrape.proxy")
+	}
+
+	networkSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "network" {
+			networkSet = true
+		}
+	})
+	networkName, genesisTime, secondsPerSlot, slotsPerEpoch := options.Network, uint64(0), uint64(0), uint64(0)
+	if !networkSet && options.BeaconnodeAPI != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), endpointTimeout(options.BeaconnodeTimeout))
+		name, gt, sps, spe, err := getBeaconAPINetwork(ctx, options.BeaconnodeAPI, &options.BeaconnodeAuth)
+		cancel()
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"error": err, "network": options.Network}).Warn("failed detecting network via beaconnode.api, using default network")
+		} else {
+			logrus.WithFields(logrus.Fields{"network": name}).Info("detected network via beaconnode.api")
+			networkName, genesisTime, secondsPerSlot, slotsPerEpoch = name, gt, sps, spe
+		}
+	}
+	// the network-flags take precedence over the detected values
+	if options.NetworkGenesisTime != 0 {
+		genesisTime = options.NetworkGenesisTime
+	}
+	if options.NetworkSecondsPerSlot != 0 {
+		secondsPerSlot = options.NetworkSecondsPerSlot
+	}
+	if options.NetworkSlotsPerEpoch != 0 {
+		slotsPerEpoch = options.NetworkSlotsPerEpoch
+	}
+	err = initNetwork(networkName, genesisTime, secondsPerSlot, slotsPerEpoch)
+	if err != nil {
+		logrus.WithError(err).Fatal("invalid network")
+	}
+
+	if options.AlertsConfig != "" {
+		alerts, err = newAlertEngine(options.AlertsConfig)
+		if err != nil {
+			logrus.WithError(err).Fatal("failed loading alerts.config")
+		}
 	}
 
 	beaconchain := `                                                                                                                                                                                                                                                                     
@@ -145,53 +402,154 @@ func main() {
 	fmt.Println(beaconchain)
 	logrus.WithFields(logrus.Fields{
 		// "ServerAddress": options.ServerAddress, // may contain secrets, don't log
-		"ServerTimeout":     options.ServerTimeout,
-		"BeaconnodeType":    options.BeaconnodeType,
-		"BeaconnodeAddress": options.BeaconnodeAddress,
-		"ValidatorType":     options.ValidatorType,
-		"ValidatorAddress":  options.ValidatorAddress,
-		"Interval":          options.Interval,
-		"Partition":         options.Partition,
-		"Debug":             options.Debug,
-		"Version":           exporterVersion,
+		"ServerTimeout":      options.ServerTimeout,
+		"ScrapeTimeout":      options.ScrapeTimeout,
+		"CycleTimeout":       options.CycleTimeout,
+		"ServerExtended":     options.ServerExtended,
+		"ServerCompression":  options.ServerCompression,
+		"BeaconnodeType":     options.BeaconnodeType,
+		"BeaconnodeAddress":  options.BeaconnodeAddress,
+		"BeaconnodeAPI":      options.BeaconnodeAPI,
+		"ValidatorType":      options.ValidatorType,
+		"ValidatorAddress":   options.ValidatorAddress,
+		"ValidatorAPI":       options.ValidatorAPI,
+		"ExecutionType":      options.ExecutionType,
+		"ExecutionAddress":   options.ExecutionAddress,
+		"ExecutionRPC":       options.ExecutionRPC,
+		"Interval":           options.Interval,
+		"SampleInterval":     options.SampleInterval,
+		"Partition":          options.Partition,
+		"NetInterfaces":      options.NetInterfaces,
+		"Network":            network.Name,
+		"GenesisTime":        network.GenesisTime,
+		"SecondsPerSlot":     network.SecondsPerSlot,
+		"SlotsPerEpoch":      network.SlotsPerEpoch,
+		"SyncTolerance":      options.SyncTolerance,
+		"SyncStallIntervals": options.SyncStallIntervals,
+		"AlertsConfig":       options.AlertsConfig,
+		"HealthAddress":      options.HealthAddress,
+		"ScheduleMode":       options.ScheduleMode,
+		"ScheduleJitter":     options.ScheduleJitter,
+		"BufferSize":         options.BufferSize,
+		"BufferPolicy":       options.BufferPolicy,
+		"BufferSpillDir":     options.BufferSpillDir,
+		"PushSenders":        options.PushSenders,
+		"ShutdownTimeout":    options.ShutdownTimeout,
+		"ShutdownSpoolFile":  options.ShutdownSpoolFile,
+		"Discover":           options.Discover,
+		"Debug":              options.Debug,
+		"Version":            exporterVersion,
 	}).Infof("starting exporter")
 
-	collectDataLoop()
+	if options.HealthAddress != "" {
+		go serveHealth(options.HealthAddress)
+	}
+
+	s, err := newScheduler(options.ScheduleMode, options.Interval, options.ScheduleJitter, time.Now())
+	if err != nil {
+		logrus.WithError(err).Fatal("invalid schedule")
+	}
+
+	if options.SampleInterval >= options.Interval {
+		logrus.Fatal("sample.interval must be shorter than interval")
+	}
+	if !options.ServerExtended {
+		for i, c := range clientEndpoints {
+			if c.Cgroup != "" {
+				logrus.WithFields(logrus.Fields{"flag": c.Name + ".cgroup"}).Warn("cgroup is ignored without server.extended, the cgroup-stats are only sent as extended fields")
+				clientEndpoints[i].Cgroup = ""
+			}
+		}
+	}
+	if options.SampleInterval > 0 && !options.ServerExtended {
+		logrus.Warn("sample.interval is ignored without server.extended, the samples are only sent as extended fields")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if options.SampleInterval > 0 && options.ServerExtended {
+		go runSampler(ctx, options.SampleInterval)
+	}
+	err = collectDataLoop(ctx, s)
+	stop()
+	if err != nil {
+		logrus.WithError(err).Error("failed flushing data on shutdown")
+		os.Exit(1)
+	}
+	logrus.Info("stopped exporter")
 }
 
-func collectDataLoop() {
-	t := time.NewTicker(options.Interval)
-	defer t.Stop()
-	for {
+// collectDataLoop collects and sends data until ctx is canceled, in-flight scrapes and pushes
+// are canceled then and the pending data is flushed
+func collectDataLoop(ctx context.Context, s *scheduler) error {
+	var pending []interface{}
+	if options.ShutdownSpoolFile != "" {
+		var err error
+		pending, err = loadSpooledData(options.ShutdownSpoolFile)
+		if err != nil {
+			logrus.WithError(err).Error("failed loading spooled data")
+		} else if len(pending) > 0 {
+			logrus.WithFields(logrus.Fields{"records": len(pending), "file": options.ShutdownSpoolFile}).Info("loaded spooled data")
+		}
+	}
+
+	buf, err := newBuffer(options.BufferSize, options.BufferPolicy, options.BufferSpillDir)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		buf.requeue(pending)
+	}
+	waitSenders := runSenders(ctx, buf, options.PushSenders)
+
+	for sleepUntil(ctx, s.next(time.Now())) {
 		t0 := time.Now()
-		d, err := collectData()
+		d, err := collectData(ctx)
 		if err != nil {
 			logrus.WithError(err).Error("failed collecting data")
-			time.Sleep(time.Second * 10)
-			t.Reset(options.Interval)
 			continue
 		}
 		logrus.WithFields(logrus.Fields{"duration": time.Since(t0)}).Info("collected data")
 
-		t1 := time.Now()
-		err = sendData(d)
-		if err != nil {
-			logrus.WithError(err).Error("failed sending data")
-			time.Sleep(time.Second * 10)
-			t.Reset(options.Interval)
-			continue
+		// the alerts are evaluated before the extended fields are stripped, so rules can use them
+		if alerts != nil && ctx.Err() == nil {
+			alerts.evaluate(d)
+		}
+		for _, r := range d {
+			stripExtendedData(r)
 		}
-		logrus.WithFields(logrus.Fields{"duration": time.Since(t1)}).Info("sent data")
 
-		select {
-		case <-t.C:
+		if ctx.Err() != nil {
+			buf.requeue(d)
+			break
+		}
+
+		err = buf.put(ctx, d)
+		if err != nil {
+			// canceled while blocking, the batch is flushed with the rest of the buffer
+			buf.requeue(d)
+			break
 		}
 	}
+
+	waitSenders()
+	return flushData(buf.drain())
 }
 
-func collectData() ([]interface{}, error) {
+func endpointTimeout(timeout time.Duration) time.Duration {
+	if timeout == 0 {
+		return options.ScrapeTimeout
+	}
+	return timeout
+}
+
+// collectData collects the data of all endpoints concurrently, if the cycle-deadline is
+// exceeded the data collected so far is returned
+func collectData(ctx context.Context) ([]interface{}, error) {
 	var wg sync.WaitGroup
 
+	cycleCtx, cancel := context.WithTimeout(ctx, options.CycleTimeout)
+	defer cancel()
+
 	results := make(chan interface{}, len(clientEndpoints)+1)
 	ts := uint64(time.Now().UnixNano() / int64(time.Millisecond))
 
@@ -199,10 +557,16 @@ func collectData() ([]interface{}, error) {
 	go func() {
 		defer wg.Done()
 		d, err := getSystemData(ts)
+		recordScrape("system", "system", err)
 		if err != nil {
 			logrus.WithFields(logrus.Fields{"error": err, "type": "system"}).Errorf("failed getting data")
 			return
 		}
+		setSampleData(d)
+		// the rates are sent as extended fields and served as self-metrics
+		if options.ServerExtended || options.HealthAddress != "" {
+			setRateData(d)
+		}
 		results <- d
 	}()
 
@@ -210,32 +574,42 @@ func collectData() ([]interface{}, error) {
 		wg.Add(1)
 		go func(c ClientEndpoint) {
 			defer wg.Done()
+			ctx, cancel := context.WithTimeout(cycleCtx, c.Timeout)
+			defer cancel()
+			var d interface{}
+			var err error
 			switch c.Type {
 			case PrysmBeaconnodeMetricsClientType:
-				d, err := getPrysmBeaconnodeData(c.Address, ts)
-				if err != nil {
-					logrus.WithFields(logrus.Fields{"error": err, "address": c.Address, "type": c.Type}).Errorf("failed getting data")
-					return
-				}
-				results <- d
+				d, err = getPrysmBeaconnodeData(ctx, c, ts)
 			case PrysmValidatorMetricsClientType:
-				d, err := getPrysmValidatorData(c.Address, ts)
-				if err != nil {
-					logrus.WithFields(logrus.Fields{"error": err, "address": c.Address, "type": c.Type}).Errorf("failed getting data")
-					return
+				var v *ValidatorData
+				v, err = getPrysmValidatorData(ctx, c, ts)
+				if err == nil && c.KeymanagerAddress != "" {
+					err = setValidatorDataFromAPI(ctx, c, v)
 				}
-				results <- d
+				d = v
 			case NimbusBeaconnodeMetricsClientType:
-				d, err := getNimbusBeaconnodeData(c.Address, ts)
-				if err != nil {
-					logrus.WithFields(logrus.Fields{"error": err, "address": c.Address, "type": c.Type}).Errorf("failed getting data")
-					return
-				}
-				results <- d
+				d, err = getNimbusBeaconnodeData(ctx, c, ts)
+			case GethExecutionClientType, NethermindExecutionClientType, BesuExecutionClientType, ErigonExecutionClientType:
+				d, err = getExecutionData(ctx, c, ts)
+			case ValidatorAPIClientType:
+				d, err = getValidatorAPIData(ctx, c, options.ValidatorType, ts)
+			case AutoBeaconnodeClientType, AutoValidatorClientType:
+				d, err = getAutoDetectedData(ctx, c, ts)
 			default:
 				logrus.Fatalf("unknown client-endpoint-type: %v", c.Type)
 			}
-			return
+			recordScrape(c.Name, c.Type, err)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{"error": err, "reason": scrapeErrorReason(err), "flag": c.Name + ".address", "address": c.Address, "rpc": c.RPCAddress, "api": c.KeymanagerAddress, "type": c.Type}).Errorf("failed getting data")
+				return
+			}
+			setSampleData(d)
+			if options.ServerExtended || options.HealthAddress != "" {
+				setRateData(d)
+			}
+			setCgroupData(ctx, c, d)
+			results <- d
 		}(c)
 	}
 
@@ -245,13 +619,25 @@ func collectData() ([]interface{}, error) {
 	}()
 
 	result := []interface{}{}
-	for r := range results {
-		result = append(result, r)
+	for {
+		select {
+		case r, ok := <-results:
+			if !ok {
+				return result, nil
+			}
+			result = append(result, r)
+		case <-cycleCtx.Done():
+			if ctx.Err() != nil {
+				logrus.WithFields(logrus.Fields{"collected": len(result), "endpoints": len(clientEndpoints) + 1}).Warn("shutting down, canceled collecting data")
+				return result, nil
+			}
+			logrus.WithFields(logrus.Fields{"collected": len(result), "endpoints": len(clientEndpoints) + 1}).Warn("cycle deadline exceeded, continuing with partial data")
+			return result, nil
+		}
 	}
-	return result, nil
 }
 
-func sendData(data []interface{}) error {
+func sendData(ctx context.Context, data []interface{}) error {
 	dataJSON, err := json.Marshal(data)
 	if err != nil {
 		err = fmt.Errorf("failed marshaling data: %w", err)
@@ -260,13 +646,23 @@ func sendData(data []interface{}) error {
 
 	logrus.WithFields(logrus.Fields{"json": fmt.Sprintf("%s", dataJSON)}).Debug("sending data")
 
-	req, err := http.NewRequest("POST", options.ServerAddress, bytes.NewBuffer(dataJSON))
+	encoding := getServerEncoding()
+	payload, err := compressPayload(encoding, dataJSON)
+	if err != nil {
+		err = fmt.Errorf("failed compressing data: %w", err)
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", options.ServerAddress, bytes.NewBuffer(payload))
 	if err != nil {
 		err = fmt.Errorf("failed creating request: %w", err)
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	res, err := httpClient.Do(req)
+	if encoding != "none" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	res, err := serverHTTPClient.Do(req)
 	if err != nil {
 		err = fmt.Errorf("failed sending request: %w", err)
 		return err
@@ -277,19 +673,33 @@ func sendData(data []interface{}) error {
 		return err
 	}
 
+	if negotiateServerEncoding(encoding, res) {
+		return sendData(ctx, data)
+	}
+
 	if res.StatusCode != http.StatusOK || len(body) != 0 {
-		err = fmt.Errorf("got error-response from server: %s", body)
-		return err
+		return &serverError{StatusCode: res.StatusCode, Body: body}
 	}
 	return nil
 }
 
+// serverError is an error-response of the server
+type serverError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *serverError) Error() string {
+	return fmt.Sprintf("got error-response from server: %v: %s", e.StatusCode, e.Body)
+}
+
 func getSystemData(ts uint64) (*SystemData, error) {
 	systemData := &SystemData{}
 
 	systemData.Version = specVersion
 	systemData.Timestamp = ts
 	systemData.ExporterVersion = exporterVersion
+	systemData.CommonNetworkData = &CommonNetworkData{Network: network.Name}
 	systemData.Process = "system"
 
 	cpuThreads, err := cpu.Counts(true)
@@ -308,13 +718,7 @@ func getSystemData(ts uint64) (*SystemData, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed getting cpu times: %w", err)
 	}
-	for _, t := range cpuTimes {
-		systemData.CPUNodeIdleSecondsTotal += uint64(t.Idle)
-		systemData.CPUNodeUserSecondsTotal += uint64(t.User)
-		systemData.CPUNodeIOWaitSecondsTotal += uint64(t.Iowait)
-		// note: currently beaconcha.in expects this to be everything
-		systemData.CPUNodeSystemSecondsTotal += uint64(t.System) + uint64(t.Iowait) + uint64(t.User) + uint64(t.Idle)
-	}
+	systemData.setCPUNodeSeconds(newCPUSeconds(cpuTimes))
 
 	memStat, err := mem.VirtualMemory()
 	if err != nil {
@@ -353,26 +757,24 @@ func getSystemData(ts uint64) (*SystemData, error) {
 		logrus.WithFields(logrus.Fields{"path": mostUsedPartStat.Path, "usedPercent": mostUsedPartStat.UsedPercent, "totalBytes": mostUsedPartStat.Total, "freeBytes": mostUsedPartStat.Free}).Infof("highest disk usage: %2.f%%", mostUsedPartStat.UsedPercent)
 	}
 
-	ioCounters, err := disk.IOCounters()
+	ioCounters, err := getDiskIOCounters()
 	if err != nil {
 		return nil, fmt.Errorf("failed getting disk io counterss: %w", err)
 	}
-	for _, c := range ioCounters {
-		_ = c
-		systemData.DiskNodeIOSeconds = c.IoTime
-		systemData.DiskNodeReadsTotal = c.ReadCount   // c.MergedReadCount ?
-		systemData.DiskNodeWritesTotal = c.WriteCount // c.MergedWriteCount ?
-	}
+	systemData.DiskNodeIOSeconds = ioCounters.IoTime
+	systemData.DiskNodeReadsTotal = ioCounters.ReadCount   // c.MergedReadCount ?
+	systemData.DiskNodeWritesTotal = ioCounters.WriteCount // c.MergedWriteCount ?
 
-	netCounters, err := net.IOCounters(false)
+	netCounters, netInterfaceCounters, err := getNetIOCounters()
 	if err != nil {
 		return nil, fmt.Errorf("failed getting net io counters: %w", err)
 	}
-	if len(netCounters) == 0 {
-		return nil, fmt.Errorf("no net.IOCounters")
+	systemData.NetworkNodeBytesTotalReceive = netCounters.BytesRecv
+	systemData.NetworkNodeBytesTotalTransmit = netCounters.BytesSent
+	systemData.SystemNetworkData = &SystemNetworkData{NetworkNodeInterfaces: map[string]NetworkInterfaceData{}}
+	for _, c := range netInterfaceCounters {
+		systemData.NetworkNodeInterfaces[c.Name] = NetworkInterfaceData{BytesTotalReceive: c.BytesRecv, BytesTotalTransmit: c.BytesSent}
 	}
-	systemData.NetworkNodeBytesTotalReceive = netCounters[0].BytesRecv
-	systemData.NetworkNodeBytesTotalTransmit = netCounters[0].BytesSent
 
 	bootTime, err := host.BootTime()
 	if err != nil {
@@ -385,6 +787,19 @@ func getSystemData(ts uint64) (*SystemData, error) {
 		systemData.MiscOS = systemData.MiscOS[:3]
 	}
 
+	// reading the host-data is expensive (eg: /proc/net/tcp), skip it if it would be stripped
+	if options.ServerExtended {
+		systemData.SystemHostData = getSystemHostData()
+	}
+
+	clockData, err := getClockData()
+	if err != nil {
+		logrus.WithError(err).Debug("failed getting clock synchronization state")
+	} else {
+		checkClockSynced(clockData)
+		systemData.SystemClockData = clockData
+	}
+
 	return systemData, nil
 }
 
@@ -412,43 +827,75 @@ func getMetricValueFromFamilyMap(m map[string]*promModel.MetricFamily, name stri
 	return 0
 }
 
-func getMetrics(endpoint string) (map[string]*promModel.MetricFamily, error) {
-	req, err := http.NewRequest("GET", endpoint, nil)
+// getMetricValueSumFromFamilyMap sums up the values of all metrics of the given family
+func getMetricValueSumFromFamilyMap(m map[string]*promModel.MetricFamily, name string) float64 {
+	sum := float64(0)
+	metricFamily, exists := m[name]
+	if exists {
+		for _, m := range metricFamily.GetMetric() {
+			sum += getMetricValue(m)
+		}
+	}
+	return sum
+}
+
+func getMetrics(ctx context.Context, endpoint string, auth *EndpointAuth) (map[string]*promModel.MetricFamily, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Cache-control", "no-cache")
-	res, err := httpClient.Do(req)
+	req.Header.Set("Accept", scrapeAcceptHeader)
+	err = auth.apply(req)
 	if err != nil {
 		return nil, err
 	}
+	res, err := scrapeHTTPClient.Do(req)
+	if err != nil {
+		return nil, newScrapeRequestError(endpoint, err)
+	}
 	defer res.Body.Close()
 
-	var parser promExpfmt.TextParser
-	metricFamilies, err := parser.TextToMetricFamilies(res.Body)
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, &ScrapeError{URL: endpoint, Reason: ScrapeErrorStatus, StatusCode: res.StatusCode}
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	mediatype, _, _ := mime.ParseMediaType(contentType)
+	switch mediatype {
+	case "", "text/plain", promExpfmt.OpenMetricsType, promExpfmt.ProtoType:
+	default:
+		return nil, &ScrapeError{URL: endpoint, Reason: ScrapeErrorContentType, ContentType: contentType}
+	}
+
+	metricFamilies, err := parseMetrics(res)
 	if err != nil {
-		return nil, err
+		return nil, &ScrapeError{URL: endpoint, Reason: ScrapeErrorParse, Err: err}
 	}
 
 	return metricFamilies, nil
 }
 
-func getPrysmBeaconnodeData(endpoint string, ts uint64) (*BeaconnodeData, error) {
-	metrics, err := getMetrics(endpoint)
+func getPrysmBeaconnodeData(ctx context.Context, c ClientEndpoint, ts uint64) (*BeaconnodeData, error) {
+	metrics, err := getMetrics(ctx, c.Address, &c.Auth)
 	if err != nil {
 		return nil, err
 	}
+	return newPrysmBeaconnodeData(c, metrics, ts), nil
+}
 
+func newPrysmBeaconnodeData(c ClientEndpoint, metrics map[string]*promModel.MetricFamily, ts uint64) *BeaconnodeData {
 	data := &BeaconnodeData{}
 
 	// CommonData
 	data.Version = specVersion
 	data.Timestamp = ts
 	data.ExporterVersion = exporterVersion
+	data.CommonNetworkData = &CommonNetworkData{Network: network.Name}
 	data.Process = "beaconnode"
 
 	// ProcessData
-	data.CPUProcessSecondsTotal = uint64(getMetricValueFromFamilyMap(metrics, "process_cpu_seconds_total"))
+	data.setCPUProcessSeconds(getMetricValueFromFamilyMap(metrics, "process_cpu_seconds_total"))
 	data.MemoryProcessBytes = uint64(getMetricValueFromFamilyMap(metrics, "process_resident_memory_bytes"))
 	data.ClientName = "prysm"
 
@@ -501,31 +948,35 @@ func getPrysmBeaconnodeData(endpoint string, ts uint64) (*BeaconnodeData, error)
 	}
 
 	data.SyncEth1Connected = true // todo
-	data.SyncEth2Synced = true    // todo
 	data.SyncBeaconHeadSlot = uint64(getMetricValueFromFamilyMap(metrics, "beacon_head_slot"))
+	setBeaconnodeSyncData(c.Address, data)
 	data.SyncEth1FallbackConfigured = false
 	data.SyncEth1FallbackConnected = false
 	data.SlasherActive = false
 
-	return data, nil
+	return data
 }
 
-func getPrysmValidatorData(endpoint string, ts uint64) (*ValidatorData, error) {
-	metrics, err := getMetrics(endpoint)
+func getPrysmValidatorData(ctx context.Context, c ClientEndpoint, ts uint64) (*ValidatorData, error) {
+	metrics, err := getMetrics(ctx, c.Address, &c.Auth)
 	if err != nil {
 		return nil, err
 	}
+	return newPrysmValidatorData(c, metrics, ts), nil
+}
 
+func newPrysmValidatorData(c ClientEndpoint, metrics map[string]*promModel.MetricFamily, ts uint64) *ValidatorData {
 	data := &ValidatorData{}
 
 	// CommonData
 	data.Version = specVersion
 	data.Timestamp = ts
 	data.ExporterVersion = exporterVersion
+	data.CommonNetworkData = &CommonNetworkData{Network: network.Name}
 	data.Process = "validator"
 
 	// ProcessData
-	data.CPUProcessSecondsTotal = uint64(getMetricValueFromFamilyMap(metrics, "process_cpu_seconds_total"))
+	data.setCPUProcessSeconds(getMetricValueFromFamilyMap(metrics, "process_cpu_seconds_total"))
 	data.MemoryProcessBytes = uint64(getMetricValueFromFamilyMap(metrics, "process_resident_memory_bytes"))
 	data.ClientName = "prysm"
 
@@ -548,37 +999,53 @@ func getPrysmValidatorData(endpoint string, ts uint64) (*ValidatorData, error) {
 	data.SyncEth2FallbackConnected = false
 
 	// ValidatorData
+	counter := newValidatorStatusCounter()
 
 	validatorStatuses, exists := metrics["validator_statuses"]
 	if exists {
 		ms := validatorStatuses.GetMetric()
 		for _, m := range ms {
-			data.ValidatorTotal++
-			if getMetricValue(m) == 3 {
-				data.ValidatorActive++
-			}
+			state := validatorState(getMetricValue(m))
+			counter.add(state, state == validatorStateSlashing)
 		}
 	}
 
-	return data, nil
+	// prysm exports the balances in ETH
+	validatorBalances, exists := metrics["validator_balance"]
+	if exists {
+		ms := validatorBalances.GetMetric()
+		for _, m := range ms {
+			counter.addBalance(uint64(math.Round(getMetricValue(m) * 1e9)))
+		}
+	}
+
+	counter.setValidatorData(data)
+
+	data.ValidatorPerformanceData = getValidatorPerformanceData(metrics, prysmValidatorPerformanceMetricNames)
+
+	return data
 }
 
-func getNimbusBeaconnodeData(endpoint string, ts uint64) (*BeaconnodeData, error) {
-	metrics, err := getMetrics(endpoint)
+func getNimbusBeaconnodeData(ctx context.Context, c ClientEndpoint, ts uint64) (*BeaconnodeData, error) {
+	metrics, err := getMetrics(ctx, c.Address, &c.Auth)
 	if err != nil {
 		return nil, err
 	}
+	return newNimbusBeaconnodeData(c, metrics, ts), nil
+}
 
+func newNimbusBeaconnodeData(c ClientEndpoint, metrics map[string]*promModel.MetricFamily, ts uint64) *BeaconnodeData {
 	data := &BeaconnodeData{}
 
 	// CommonData
 	data.Version = specVersion
 	data.Timestamp = ts
 	data.ExporterVersion = exporterVersion
+	data.CommonNetworkData = &CommonNetworkData{Network: network.Name}
 	data.Process = "beaconnode"
 
 	// ProcessData
-	data.CPUProcessSecondsTotal = uint64(getMetricValueFromFamilyMap(metrics, "process_cpu_seconds_total"))
+	data.setCPUProcessSeconds(getMetricValueFromFamilyMap(metrics, "process_cpu_seconds_total"))
 	data.MemoryProcessBytes = uint64(getMetricValueFromFamilyMap(metrics, "process_resident_memory_bytes"))
 	data.ClientName = "nimbus"
 
@@ -604,7 +1071,7 @@ func getNimbusBeaconnodeData(endpoint string, ts uint64) (*BeaconnodeData, error
 	data.SyncBeaconHeadSlot = uint64(getMetricValueFromFamilyMap(metrics, "beacon_head_slot"))
 
 	data.SyncEth1Connected = true // todo
-	data.SyncEth2Synced = true    // todo
+	setBeaconnodeSyncData(c.Address, data)
 
-	return data, nil
+	return data
 }
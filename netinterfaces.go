@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/shirou/gopsutil/net"
+	"github.com/sirupsen/logrus"
+)
+
+// virtualInterfacePatterns are excluded by default on systems without /sys/class/net
+var virtualInterfacePatterns = []string{"lo", "lo0", "docker*", "veth*", "br-*", "virbr*", "vnet*", "cni*", "flannel*", "cali*"}
+
+// netInterfaceFilter selects the interfaces whose counters are summed up, see --system.net-interfaces
+type netInterfaceFilter struct {
+	include []string
+	exclude []string
+}
+
+// newNetInterfaceFilter parses comma-separated globs, globs prefixed with ! exclude interfaces
+func newNetInterfaceFilter(patterns string) (*netInterfaceFilter, error) {
+	f := &netInterfaceFilter{}
+	for _, p := range strings.Split(patterns, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		exclude := strings.HasPrefix(p, "!")
+		p = strings.TrimPrefix(p, "!")
+		if _, err := filepath.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", p, err)
+		}
+		if exclude {
+			f.exclude = append(f.exclude, p)
+		} else {
+			f.include = append(f.include, p)
+		}
+	}
+	return f, nil
+}
+
+// match returns true if the interface is selected, without include-globs all interfaces but the
+// loopback and virtual ones are selected
+func (f *netInterfaceFilter) match(name string) bool {
+	for _, p := range f.exclude {
+		if ok, _ := filepath.Match(p, name); ok {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return !isVirtualInterface(name)
+	}
+	for _, p := range f.include {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isVirtualInterface checks if the device of the interface is virtual (like lo, bridges, veths
+// and tunnels) via sysfs and falls back to well-known names
+func isVirtualInterface(name string) bool {
+	sys := os.Getenv("HOST_SYS")
+	if sys == "" {
+		sys = "/sys"
+	}
+	if target, err := filepath.EvalSymlinks(filepath.Join(sys, "class", "net", name)); err == nil {
+		return strings.Contains(target, "/devices/virtual/")
+	}
+	for _, p := range virtualInterfacePatterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+var netInterfaces = &netInterfaceFilter{}
+
+// warnNoNetInterface warns once that the filter matches no interface
+var warnNoNetInterface sync.Once
+
+// getNetIOCounters returns the counters of the selected interfaces and their sum, if no interface
+// is selected the counters of all interfaces but the loopback and virtual ones are returned
+func getNetIOCounters() (net.IOCountersStat, []net.IOCountersStat, error) {
+	total := net.IOCountersStat{Name: "all"}
+	counters, err := net.IOCounters(true)
+	if err != nil {
+		return total, nil, err
+	}
+	selected := []net.IOCountersStat{}
+	for _, c := range counters {
+		if netInterfaces.match(c.Name) {
+			selected = append(selected, c)
+		}
+	}
+	if len(selected) == 0 {
+		warnNoNetInterface.Do(func() {
+			logrus.Warn("no network interface matches system.net-interfaces, counting all interfaces but the loopback and virtual ones")
+		})
+		for _, c := range counters {
+			if !isVirtualInterface(c.Name) {
+				selected = append(selected, c)
+			}
+		}
+	}
+	for _, c := range selected {
+		total.BytesRecv += c.BytesRecv
+		total.BytesSent += c.BytesSent
+		total.PacketsRecv += c.PacketsRecv
+		total.PacketsSent += c.PacketsSent
+		total.Errin += c.Errin
+		total.Errout += c.Errout
+		total.Dropin += c.Dropin
+		total.Dropout += c.Dropout
+	}
+	return total, selected, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type NetworkConfig struct {
+	Name           string
+	GenesisTime    uint64 // unix timestamp in seconds
+	SecondsPerSlot uint64
+	SlotsPerEpoch  uint64
+}
+
+var networkPresets = map[string]NetworkConfig{
+	"mainnet": {Name: "mainnet", GenesisTime: 1606824023, SecondsPerSlot: 12, SlotsPerEpoch: 32},
+	"holesky": {Name: "holesky", GenesisTime: 1695902400, SecondsPerSlot: 12, SlotsPerEpoch: 32},
+	"sepolia": {Name: "sepolia", GenesisTime: 1655733600, SecondsPerSlot: 12, SlotsPerEpoch: 32},
+	"gnosis":  {Name: "gnosis", GenesisTime: 1638993340, SecondsPerSlot: 5, SlotsPerEpoch: 16},
+	"custom":  {Name: "custom", SlotsPerEpoch: 32},
+}
+
+var network NetworkConfig
+
+// initNetwork sets the network from the given preset, genesisTime, secondsPerSlot and slotsPerEpoch override the values of the preset if not 0
+func initNetwork(preset string, genesisTime, secondsPerSlot, slotsPerEpoch uint64) error {
+	n, exists := networkPresets[preset]
+	if !exists {
+		return fmt.Errorf("unknown network: %v", preset)
+	}
+	if genesisTime != 0 {
+		n.GenesisTime = genesisTime
+	}
+	if secondsPerSlot != 0 {
+		n.SecondsPerSlot = secondsPerSlot
+	}
+	if slotsPerEpoch != 0 {
+		n.SlotsPerEpoch = slotsPerEpoch
+	}
+	if n.GenesisTime == 0 || n.SecondsPerSlot == 0 {
+		return fmt.Errorf("network %v requires network.genesis-time and network.seconds-per-slot", preset)
+	}
+	network = n
+	return nil
+}
+
+type beaconGenesisResponse struct {
+	Data struct {
+		GenesisTime uint64 `json:"genesis_time,string"`
+	} `json:"data"`
+}
+
+type beaconSpecResponse struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// getBeaconAPINetwork returns the preset of the network of the beaconnode by its genesis-time, or
+// a custom network with the genesis-time and the slot-config of its spec if the network is unknown
+func getBeaconAPINetwork(ctx context.Context, endpoint string, auth *EndpointAuth) (string, uint64, uint64, uint64, error) {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	genesis := &beaconGenesisResponse{}
+	err := getAPIJSON(ctx, endpoint+"/eth/v1/beacon/genesis", auth, "", genesis)
+	if err != nil {
+		return "", 0, 0, 0, fmt.Errorf("failed getting genesis from beacon-api: %w", err)
+	}
+	for name, n := range networkPresets {
+		if n.GenesisTime != 0 && n.GenesisTime == genesis.Data.GenesisTime {
+			return name, 0, 0, 0, nil
+		}
+	}
+
+	spec := &beaconSpecResponse{}
+	err = getAPIJSON(ctx, endpoint+"/eth/v1/config/spec", auth, "", spec)
+	if err != nil {
+		return "", 0, 0, 0, fmt.Errorf("failed getting spec from beacon-api: %w", err)
+	}
+	secondsPerSlot, err := strconv.ParseUint(fmt.Sprint(spec.Data["SECONDS_PER_SLOT"]), 10, 64)
+	if err != nil {
+		return "", 0, 0, 0, fmt.Errorf("failed parsing SECONDS_PER_SLOT: %w", err)
+	}
+	slotsPerEpoch, err := strconv.ParseUint(fmt.Sprint(spec.Data["SLOTS_PER_EPOCH"]), 10, 64)
+	if err != nil {
+		return "", 0, 0, 0, fmt.Errorf("failed parsing SLOTS_PER_EPOCH: %w", err)
+	}
+	return "custom", genesis.Data.GenesisTime, secondsPerSlot, slotsPerEpoch, nil
+}
+
+// wallSlot returns the slot at the given unix timestamp in milliseconds
+func (n *NetworkConfig) wallSlot(ts uint64) uint64 {
+	genesisMs := n.GenesisTime * 1000
+	if ts < genesisMs || n.SecondsPerSlot == 0 {
+		return 0
+	}
+	return (ts - genesisMs) / (n.SecondsPerSlot * 1000)
+}
+
+func (n *NetworkConfig) epoch(slot uint64) uint64 {
+	if n.SlotsPerEpoch == 0 {
+		return 0
+	}
+	return slot / n.SlotsPerEpoch
+}
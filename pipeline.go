@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// buffer connects the collector with the senders, it keeps up to size batches in memory, if it
+// is full the policy decides what happens to new batches:
+//   - drop-oldest: the oldest batch is dropped
+//   - block: the collector waits until a sender took a batch
+//   - spill: new batches are written to spillDir until the senders caught up
+type buffer struct {
+	mu       sync.Mutex
+	batches  [][]interface{}
+	spilled  []string // files in spillDir, oldest first
+	spillSeq int64
+	size     int
+	policy   string
+	spillDir string
+	changed  chan struct{} // closed and replaced on every change
+}
+
+func newBuffer(size int, policy, spillDir string) (*buffer, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("buffer.size must be at least 1")
+	}
+	b := &buffer{size: size, policy: policy, spillDir: spillDir, changed: make(chan struct{})}
+	switch policy {
+	case "drop-oldest", "block":
+	case "spill":
+		if spillDir == "" {
+			return nil, fmt.Errorf("buffer.policy spill requires buffer.spill-dir")
+		}
+		err := os.MkdirAll(spillDir, 0700)
+		if err != nil {
+			return nil, fmt.Errorf("failed creating spill-dir: %w", err)
+		}
+		// batches spilled before the last shutdown are sent first
+		files, err := filepath.Glob(filepath.Join(spillDir, "*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("failed listing spill-dir: %w", err)
+		}
+		sort.Strings(files)
+		b.spilled = files
+		if len(files) > 0 {
+			logrus.WithFields(logrus.Fields{"batches": len(files), "dir": spillDir}).Info("loaded spilled data")
+		}
+	default:
+		return nil, fmt.Errorf("unknown buffer.policy: %v", policy)
+	}
+	return b, nil
+}
+
+// signal wakes up everyone waiting for a change, b.mu must be held
+func (b *buffer) signal() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// put adds a batch, it only returns an error if ctx is canceled while blocking
+func (b *buffer) put(ctx context.Context, batch []interface{}) error {
+	blocked := false
+	for {
+		b.mu.Lock()
+		if b.policy == "spill" && (len(b.spilled) > 0 || len(b.batches) >= b.size) {
+			// once spilling, new batches go to disk until it is drained to keep the order
+			err := b.spill(batch)
+			if err == nil {
+				b.signal()
+				b.mu.Unlock()
+				return nil
+			}
+			logrus.WithError(err).Error("failed spilling data")
+		}
+		if len(b.batches) < b.size {
+			b.batches = append(b.batches, batch)
+			b.signal()
+			b.mu.Unlock()
+			return nil
+		}
+		if b.policy != "block" {
+			logrus.WithFields(logrus.Fields{"records": len(b.batches[0])}).Warn("buffer is full, dropping oldest batch")
+			b.batches = append(b.batches[1:], batch)
+			b.signal()
+			b.mu.Unlock()
+			return nil
+		}
+		changed := b.changed
+		b.mu.Unlock()
+		if !blocked {
+			logrus.Warn("buffer is full, waiting for senders")
+			blocked = true
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// get takes the oldest batch, it blocks until there is one or ctx is canceled
+func (b *buffer) get(ctx context.Context) ([]interface{}, error) {
+	for {
+		b.mu.Lock()
+		if len(b.batches) > 0 {
+			batch := b.batches[0]
+			b.batches = b.batches[1:]
+			b.signal()
+			b.mu.Unlock()
+			return batch, nil
+		}
+		if len(b.spilled) > 0 {
+			file := b.spilled[0]
+			b.spilled = b.spilled[1:]
+			b.signal()
+			b.mu.Unlock()
+			batch, err := loadSpilledBatch(file)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{"error": err, "file": file}).Error("failed loading spilled data, dropping it")
+				continue
+			}
+			return batch, nil
+		}
+		changed := b.changed
+		b.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// requeue puts a batch which could not be sent back in front of the buffer, if the buffer is full
+// it is spilled with the spill-policy and dropped otherwise
+func (b *buffer) requeue(batch []interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.batches) >= b.size {
+		if b.policy == "spill" {
+			err := b.spill(batch)
+			if err == nil {
+				b.signal()
+				return
+			}
+			logrus.WithError(err).Error("failed spilling data")
+		}
+		logrus.WithFields(logrus.Fields{"records": len(batch)}).Warn("buffer is full, dropping requeued batch")
+		return
+	}
+	b.batches = append([][]interface{}{batch}, b.batches...)
+	b.signal()
+}
+
+// drain removes and returns the records of all batches in memory, spilled batches stay on disk
+func (b *buffer) drain() []interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	records := []interface{}{}
+	for _, batch := range b.batches {
+		records = append(records, batch...)
+	}
+	b.batches = nil
+	b.signal()
+	return records
+}
+
+// spill writes the batch to a new file in spillDir, b.mu must be held
+func (b *buffer) spill(batch []interface{}) error {
+	seq := time.Now().UnixNano()
+	if seq <= b.spillSeq {
+		seq = b.spillSeq + 1
+	}
+	b.spillSeq = seq
+	file := filepath.Join(b.spillDir, fmt.Sprintf("%020d.json", seq))
+	err := spoolData(file, batch)
+	if err != nil {
+		return err
+	}
+	b.spilled = append(b.spilled, file)
+	return nil
+}
+
+func loadSpilledBatch(file string) ([]interface{}, error) {
+	batch, err := loadSpooledData(file)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		return nil, fmt.Errorf("file does not exist")
+	}
+	return batch, nil
+}
+
+// sendAttempts is the maximum number of attempts to send a batch
+const sendAttempts = 5
+
+// sendRetryDelay is the delay between the attempts to send a batch
+const sendRetryDelay = time.Second * 10
+
+// isRetryableSendError returns true for network-errors and for responses of the server which
+// indicate a temporary problem (5xx and 429), other responses would be rejected again
+func isRetryableSendError(err error) bool {
+	var serverErr *serverError
+	if !errors.As(err, &serverErr) {
+		return true
+	}
+	return serverErr.StatusCode >= 500 || serverErr.StatusCode == http.StatusTooManyRequests
+}
+
+// sendLoop sends the batches of the buffer until ctx is canceled, batches which failed with a
+// retryable error are retried up to sendAttempts times, others are dropped
+func sendLoop(ctx context.Context, b *buffer) {
+	for {
+		batch, err := b.get(ctx)
+		if err != nil {
+			return
+		}
+		for attempt := 1; ; attempt++ {
+			t0 := time.Now()
+			err = sendData(ctx, batch)
+			if err != nil && ctx.Err() != nil {
+				b.requeue(batch)
+				return
+			}
+			recordPush(err)
+			if err == nil {
+				logrus.WithFields(logrus.Fields{"duration": time.Since(t0), "records": len(batch)}).Info("sent data")
+				break
+			}
+			logger := logrus.WithFields(logrus.Fields{"error": err, "records": len(batch), "attempt": attempt})
+			if !isRetryableSendError(err) {
+				logger.Error("server rejected data, dropping it")
+				break
+			}
+			if attempt >= sendAttempts {
+				logger.Error("failed sending data, giving up and dropping it")
+				break
+			}
+			logger.Error("failed sending data, retrying")
+			if !sleepUntil(ctx, time.Now().Add(sendRetryDelay)) {
+				b.requeue(batch)
+				return
+			}
+		}
+	}
+}
+
+// runSenders starts n sendLoops and returns a function which waits for them to return
+func runSenders(ctx context.Context, b *buffer, n int) func() {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sendLoop(ctx, b)
+		}()
+	}
+	return wg.Wait
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// batchIDs returns the records of the batches as json, spilled batches are loaded as json.RawMessage
+func batchIDs(t *testing.T, batches ...[]interface{}) []string {
+	ids := []string{}
+	for _, batch := range batches {
+		for _, r := range batch {
+			idJSON, err := json.Marshal(r)
+			if err != nil {
+				t.Fatalf("failed marshaling record: %v", err)
+			}
+			ids = append(ids, string(idJSON))
+		}
+	}
+	return ids
+}
+
+func TestBuffer(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		policy    string
+		puts      []int
+		requeues  []int // requeued after the puts
+		expected  []int // order in which get returns the batches
+		putErrors int
+		spilled   int
+	}{
+		{
+			name:     "drop-oldest keeps the newest batches",
+			size:     2,
+			policy:   "drop-oldest",
+			puts:     []int{1, 2, 3},
+			expected: []int{2, 3},
+		},
+		{
+			name:     "requeued batches are taken first",
+			size:     3,
+			policy:   "drop-oldest",
+			puts:     []int{1, 2},
+			requeues: []int{3},
+			expected: []int{3, 1, 2},
+		},
+		{
+			name:     "requeued batches are dropped if the buffer is full",
+			size:     2,
+			policy:   "drop-oldest",
+			puts:     []int{1, 2},
+			requeues: []int{3},
+			expected: []int{1, 2},
+		},
+		{
+			name:      "block returns an error if canceled while full",
+			size:      2,
+			policy:    "block",
+			puts:      []int{1, 2, 3},
+			expected:  []int{1, 2},
+			putErrors: 1,
+		},
+		{
+			name:     "spill keeps the order",
+			size:     2,
+			policy:   "spill",
+			puts:     []int{1, 2, 3, 4},
+			expected: []int{1, 2, 3, 4},
+			spilled:  2,
+		},
+		{
+			name:     "spill requeues in front if there is space",
+			size:     2,
+			policy:   "spill",
+			puts:     []int{1},
+			requeues: []int{2},
+			expected: []int{2, 1},
+		},
+		{
+			name:     "spill spills requeued batches if the buffer is full",
+			size:     1,
+			policy:   "spill",
+			puts:     []int{1},
+			requeues: []int{2},
+			expected: []int{1, 2},
+			spilled:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spillDir := ""
+			if tt.policy == "spill" {
+				spillDir = t.TempDir()
+			}
+			b, err := newBuffer(tt.size, tt.policy, spillDir)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// canceled, so put and get return instead of blocking
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			putErrors := 0
+			for _, id := range tt.puts {
+				if b.put(ctx, []interface{}{id}) != nil {
+					putErrors++
+				}
+			}
+			for _, id := range tt.requeues {
+				b.requeue([]interface{}{id})
+			}
+			if putErrors != tt.putErrors {
+				t.Errorf("got %v put-errors, expected %v", putErrors, tt.putErrors)
+			}
+			if spillDir != "" {
+				files, _ := filepath.Glob(filepath.Join(spillDir, "*.json"))
+				if len(files) != tt.spilled {
+					t.Errorf("got %v spilled batches, expected %v", len(files), tt.spilled)
+				}
+			}
+
+			batches := [][]interface{}{}
+			for {
+				batch, err := b.get(ctx)
+				if err != nil {
+					break
+				}
+				batches = append(batches, batch)
+			}
+			expected := []string{}
+			for _, id := range tt.expected {
+				expected = append(expected, fmt.Sprint(id))
+			}
+			if got := batchIDs(t, batches...); !reflect.DeepEqual(got, expected) {
+				t.Errorf("got batches %v, expected %v", got, expected)
+			}
+		})
+	}
+}
+
+func TestBufferDrainAndReloadSpilled(t *testing.T) {
+	spillDir := t.TempDir()
+	b, err := newBuffer(1, "spill", spillDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, id := range []int{1, 2, 3} {
+		err = b.put(context.Background(), []interface{}{id})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := batchIDs(t, b.drain()); !reflect.DeepEqual(got, []string{"1"}) {
+		t.Errorf("got drained %v, expected only the batch in memory", got)
+	}
+
+	// the spilled batches are sent after a restart
+	b, err = newBuffer(1, "spill", spillDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	batches := [][]interface{}{}
+	for {
+		batch, err := b.get(ctx)
+		if err != nil {
+			break
+		}
+		batches = append(batches, batch)
+	}
+	if got := batchIDs(t, batches...); !reflect.DeepEqual(got, []string{"2", "3"}) {
+		t.Errorf("got reloaded %v, expected [2 3]", got)
+	}
+}
+
+func TestIsRetryableSendError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "network-error", err: errors.New("connection refused"), expected: true},
+		{name: "internal server error", err: &serverError{StatusCode: 500}, expected: true},
+		{name: "service unavailable", err: &serverError{StatusCode: 503}, expected: true},
+		{name: "too many requests", err: &serverError{StatusCode: 429}, expected: true},
+		{name: "bad request", err: &serverError{StatusCode: 400}, expected: false},
+		{name: "payload too large", err: &serverError{StatusCode: 413}, expected: false},
+		{name: "wrapped server error", err: fmt.Errorf("failed sending data: %w", &serverError{StatusCode: 401}), expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableSendError(tt.err); got != tt.expected {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
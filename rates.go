@@ -0,0 +1,128 @@
+package main
+
+import (
+	"sort"
+	"sync"
+
+	promModel "github.com/prometheus/client_model/go"
+)
+
+type counterSample struct {
+	ts    uint64 // unix timestamp in milliseconds
+	value float64
+}
+
+// counters keeps the previous value of the counters per endpoint to compute their rates, the
+// latest rates are exposed on /metrics
+var counters = struct {
+	sync.Mutex
+	prev  map[string]map[string]counterSample
+	rates map[string]map[string]float64
+}{prev: map[string]map[string]counterSample{}, rates: map[string]map[string]float64{}}
+
+// counterDelta returns the increase of the counter since the previous call and the seconds
+// in between, if the counter went backwards it was reset (eg: after a reboot or a restart of
+// the client) and the increase is its current value
+func counterDelta(endpoint, name string, ts uint64, value float64) (delta, seconds float64, ok bool) {
+	counters.Lock()
+	defer counters.Unlock()
+	if counters.prev[endpoint] == nil {
+		counters.prev[endpoint] = map[string]counterSample{}
+	}
+	prev, exists := counters.prev[endpoint][name]
+	counters.prev[endpoint][name] = counterSample{ts: ts, value: value}
+	if !exists || ts <= prev.ts {
+		return 0, 0, false
+	}
+	delta = value - prev.value
+	if delta < 0 {
+		delta = value
+	}
+	return delta, float64(ts-prev.ts) / 1000, true
+}
+
+// counterRate returns the per-second rate of the counter since the previous call
+func counterRate(endpoint, name string, ts uint64, value float64) (float64, bool) {
+	delta, seconds, ok := counterDelta(endpoint, name, ts, value)
+	if !ok {
+		return 0, false
+	}
+	rate := delta / seconds
+	setRate(endpoint, name, rate)
+	return rate, true
+}
+
+func setRate(endpoint, name string, value float64) {
+	counters.Lock()
+	defer counters.Unlock()
+	if counters.rates[endpoint] == nil {
+		counters.rates[endpoint] = map[string]float64{}
+	}
+	counters.rates[endpoint][name] = value
+}
+
+// setRateData computes the rates of the counters of the collected data and sets them on the
+// extended fields
+func setRateData(d interface{}) {
+	switch d := d.(type) {
+	case *SystemData:
+		r := &SystemRateData{}
+		busyDelta, _, busyOk := counterDelta("system", "cpu_node_busy_seconds_total", d.Timestamp, d.cpuNodeSeconds.busy())
+		totalDelta, _, totalOk := counterDelta("system", "cpu_node_seconds_total", d.Timestamp, d.cpuNodeSeconds.System)
+		if utilization, ok := cpuUtilizationPercent(busyDelta, totalDelta); busyOk && totalOk && ok {
+			r.CPUNodeUtilizationPercent = utilization
+			setRate("system", "cpu_node_utilization_percent", r.CPUNodeUtilizationPercent)
+		}
+		var ok bool
+		r.NetworkNodeReceiveBytesPerSecond, ok = counterRate("system", "network_node_receive_bytes_per_second", d.Timestamp, float64(d.NetworkNodeBytesTotalReceive))
+		r.NetworkNodeTransmitBytesPerSecond, _ = counterRate("system", "network_node_transmit_bytes_per_second", d.Timestamp, float64(d.NetworkNodeBytesTotalTransmit))
+		r.DiskNodeReadsPerSecond, _ = counterRate("system", "disk_node_reads_per_second", d.Timestamp, float64(d.DiskNodeReadsTotal))
+		r.DiskNodeWritesPerSecond, _ = counterRate("system", "disk_node_writes_per_second", d.Timestamp, float64(d.DiskNodeWritesTotal))
+		// there are no rates on the first collection
+		if ok {
+			d.SystemRateData = r
+		}
+	case *BeaconnodeData:
+		r := &BeaconnodeRateData{}
+		var ok bool
+		r.NetworkLibP2PMessagesReceivedPerSecond, ok = counterRate("beaconnode", "network_libp2p_messages_received_per_second", d.Timestamp, float64(d.NetworkLibP2PBytesTotalReceive))
+		if ok {
+			d.BeaconnodeRateData = r
+		}
+	}
+}
+
+// rateMetricFamilies returns a gauge per rate, labeled by endpoint
+func rateMetricFamilies() []*promModel.MetricFamily {
+	counters.Lock()
+	defer counters.Unlock()
+
+	gauge := promModel.MetricType_GAUGE
+	families := map[string]*promModel.MetricFamily{}
+	for endpoint, rates := range counters.rates {
+		for name, value := range rates {
+			familyName := "eth2_client_metrics_exporter_" + name
+			mf, exists := families[familyName]
+			if !exists {
+				help := "Rate of the counter between the last two collections."
+				mf = &promModel.MetricFamily{Name: &familyName, Help: &help, Type: &gauge}
+				families[familyName] = mf
+			}
+			labelName, labelValue, v := "endpoint", endpoint, value
+			mf.Metric = append(mf.Metric, &promModel.Metric{
+				Label: []*promModel.LabelPair{{Name: &labelName, Value: &labelValue}},
+				Gauge: &promModel.Gauge{Value: &v},
+			})
+		}
+	}
+
+	result := []*promModel.MetricFamily{}
+	for _, mf := range families {
+		sort.Slice(mf.Metric, func(i, j int) bool {
+			return mf.Metric[i].Label[0].GetValue() < mf.Metric[j].Label[0].GetValue()
+		})
+		result = append(result, mf)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].GetName() < result[j].GetName() })
+	return result
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	promModel "github.com/prometheus/client_model/go"
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/mem"
+	"github.com/sirupsen/logrus"
+)
+
+// sampleAggregate aggregates the samples of a value since the last collection
+type sampleAggregate struct {
+	n             int
+	min, max, sum float64
+}
+
+func (a *sampleAggregate) add(v float64) {
+	if a.n == 0 || v < a.min {
+		a.min = v
+	}
+	if a.n == 0 || v > a.max {
+		a.max = v
+	}
+	a.sum += v
+	a.n++
+}
+
+func (a *sampleAggregate) avg() float64 {
+	if a.n == 0 {
+		return 0
+	}
+	return a.sum / float64(a.n)
+}
+
+// samples keeps the aggregates per endpoint and value, they are reset when taken by a collection
+var samples = struct {
+	sync.Mutex
+	m map[string]map[string]*sampleAggregate
+}{m: map[string]map[string]*sampleAggregate{}}
+
+func addSample(endpoint, name string, v float64) {
+	samples.Lock()
+	defer samples.Unlock()
+	if samples.m[endpoint] == nil {
+		samples.m[endpoint] = map[string]*sampleAggregate{}
+	}
+	a, exists := samples.m[endpoint][name]
+	if !exists {
+		a = &sampleAggregate{}
+		samples.m[endpoint][name] = a
+	}
+	a.add(v)
+}
+
+func takeSamples(endpoint string) map[string]*sampleAggregate {
+	samples.Lock()
+	defer samples.Unlock()
+	s := samples.m[endpoint]
+	delete(samples.m, endpoint)
+	return s
+}
+
+// systemSampler keeps the previous counters to sample the cpu-utilization and the network-rates
+type systemSampler struct {
+	ts          time.Time
+	cpuBusy     float64
+	cpuTotal    float64
+	netRecv     uint64
+	netTransmit uint64
+}
+
+// runSampler samples the system and the peers of the beaconnodes every interval until ctx is canceled
+func runSampler(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	s := &systemSampler{}
+	for {
+		s.sample()
+		for _, c := range clientEndpoints {
+			if c.Name == "beaconnode" {
+				samplePeers(ctx, c)
+			}
+		}
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *systemSampler) sample() {
+	now := time.Now()
+
+	memStat, err := mem.VirtualMemory()
+	if err != nil {
+		logrus.WithError(err).Error("failed sampling memory stats")
+	} else {
+		addSample("system", "memory_node_bytes_free", float64(memStat.Free))
+	}
+
+	cpuTimes, err := cpu.Times(false)
+	netCounters, _, netErr := getNetIOCounters()
+	if err != nil || netErr != nil || len(cpuTimes) == 0 {
+		logrus.WithFields(logrus.Fields{"error": err, "netError": netErr}).Error("failed sampling cpu times and net io counters")
+		s.ts = time.Time{}
+		return
+	}
+	cpuNodeSeconds := newCPUSeconds(cpuTimes)
+	cpuTotal, cpuBusy := cpuNodeSeconds.System, cpuNodeSeconds.busy()
+	netRecv, netTransmit := netCounters.BytesRecv, netCounters.BytesSent
+
+	// counters which went backwards (eg: after a reset) are skipped for one sample
+	if utilization, ok := cpuUtilizationPercent(cpuBusy-s.cpuBusy, cpuTotal-s.cpuTotal); !s.ts.IsZero() && ok {
+		addSample("system", "cpu_node_utilization_percent", utilization)
+	}
+	if seconds := now.Sub(s.ts).Seconds(); !s.ts.IsZero() && netRecv >= s.netRecv && netTransmit >= s.netTransmit {
+		addSample("system", "network_node_receive_bytes_per_second", float64(netRecv-s.netRecv)/seconds)
+		addSample("system", "network_node_transmit_bytes_per_second", float64(netTransmit-s.netTransmit)/seconds)
+	}
+	s.ts, s.cpuBusy, s.cpuTotal, s.netRecv, s.netTransmit = now, cpuBusy, cpuTotal, netRecv, netTransmit
+}
+
+func samplePeers(ctx context.Context, c ClientEndpoint) {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	metrics, err := getMetrics(ctx, c.Address, &c.Auth)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"error": err, "address": c.Address}).Debug("failed sampling peers")
+		return
+	}
+	client := detectClient(metrics)
+	switch c.Type {
+	case PrysmBeaconnodeMetricsClientType:
+		client = "prysm"
+	case NimbusBeaconnodeMetricsClientType:
+		client = "nimbus"
+	}
+	peers, ok := getBeaconnodePeers(client, metrics)
+	if ok {
+		addSample(c.Name, "network_peers_connected", peers)
+	}
+}
+
+// getBeaconnodePeers returns the connected peers of the supported beaconnode-clients
+func getBeaconnodePeers(client string, metrics map[string]*promModel.MetricFamily) (float64, bool) {
+	switch client {
+	case "prysm":
+		for _, m := range metrics["p2p_peer_count"].GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "State" && l.GetValue() == "Connected" {
+					return getMetricValue(m), true
+				}
+			}
+		}
+	case "nimbus":
+		if _, exists := metrics["nbc_peers"]; exists {
+			return getMetricValueFromFamilyMap(metrics, "nbc_peers"), true
+		}
+	}
+	return 0, false
+}
+
+// setSampleData sets the aggregated samples on the extended fields of the collected data
+func setSampleData(d interface{}) {
+	if options.SampleInterval == 0 {
+		return
+	}
+	switch d := d.(type) {
+	case *SystemData:
+		s := takeSamples("system")
+		d.SystemSampleData = &SystemSampleData{}
+		if a := s["cpu_node_utilization_percent"]; a != nil {
+			d.CPUNodeUtilizationPercentMin, d.CPUNodeUtilizationPercentMax, d.CPUNodeUtilizationPercentAvg = a.min, a.max, a.avg()
+		}
+		if a := s["memory_node_bytes_free"]; a != nil {
+			d.MemoryNodeBytesFreeMin, d.MemoryNodeBytesFreeMax, d.MemoryNodeBytesFreeAvg = a.min, a.max, a.avg()
+			d.SampleCount = int64(a.n)
+		}
+		if a := s["network_node_receive_bytes_per_second"]; a != nil {
+			d.NetworkNodeReceiveBytesPerSecondMin, d.NetworkNodeReceiveBytesPerSecondMax, d.NetworkNodeReceiveBytesPerSecondAvg = a.min, a.max, a.avg()
+		}
+		if a := s["network_node_transmit_bytes_per_second"]; a != nil {
+			d.NetworkNodeTransmitBytesPerSecondMin, d.NetworkNodeTransmitBytesPerSecondMax, d.NetworkNodeTransmitBytesPerSecondAvg = a.min, a.max, a.avg()
+		}
+	case *BeaconnodeData:
+		s := takeSamples("beaconnode")
+		if a := s["network_peers_connected"]; a != nil {
+			d.BeaconnodeSampleData = &BeaconnodeSampleData{
+				NetworkPeersConnectedMin: a.min,
+				NetworkPeersConnectedMax: a.max,
+				NetworkPeersConnectedAvg: a.avg(),
+				SampleCount:              int64(a.n),
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// scheduler triggers the collections at a fixed rate: at anchor + k*period, missed ticks are skipped
+// instead of being caught up, so slow collections or pushes don't shift the schedule
+type scheduler struct {
+	anchor time.Time // zero until the first tick in interval-mode
+	offset time.Duration
+	period time.Duration
+	prev   time.Time
+}
+
+// newScheduler creates a scheduler for the given mode:
+//   - interval: every interval since the start of the exporter
+//   - wall-clock: at the multiples of interval since the unix epoch, eg: at every full minute with --interval=1m
+//   - slot: at the slot boundaries of the network, interval is rounded up to whole slots
+//
+// A random offset of up to jitter is added to spread the pushes of many exporters
+func newScheduler(mode string, interval, jitter time.Duration, now time.Time) (*scheduler, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive")
+	}
+	s := &scheduler{period: interval}
+	switch mode {
+	case "interval":
+	case "wall-clock":
+		s.anchor = time.Unix(0, 0)
+	case "slot":
+		slot := time.Duration(network.SecondsPerSlot) * time.Second
+		s.anchor = time.Unix(int64(network.GenesisTime), 0)
+		s.period = (interval + slot - 1) / slot * slot
+	default:
+		return nil, fmt.Errorf("unknown schedule-mode: %v", mode)
+	}
+	if jitter > s.period {
+		jitter = s.period
+	}
+	if jitter > 0 {
+		s.offset = time.Duration(rand.New(rand.NewSource(now.UnixNano())).Int63n(int64(jitter)))
+	}
+	return s, nil
+}
+
+// next returns the first tick at or after now which is later than the previous tick
+func (s *scheduler) next(now time.Time) time.Time {
+	if s.anchor.IsZero() {
+		s.anchor = now
+	}
+	anchor := s.anchor.Add(s.offset)
+	t := anchor
+	if d := now.Sub(anchor); d > 0 {
+		t = anchor.Add((d + s.period - 1) / s.period * s.period)
+	}
+	if !s.prev.IsZero() && !t.After(s.prev) {
+		t = t.Add(s.period)
+	}
+	if !s.prev.IsZero() && t.Sub(s.prev) > s.period {
+		logrus.WithFields(logrus.Fields{"missed": int64(t.Sub(s.prev)/s.period) - 1}).Warn("collecting and sending data took longer than the interval, skipping missed collections")
+	}
+	s.prev = t
+	return t
+}
+
+// sleepUntil returns false if ctx is canceled before t
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedulerNext(t *testing.T) {
+	network = networkPresets["mainnet"]
+	minute := time.Unix(1599999960, 0) // a full minute
+	genesis := time.Unix(int64(network.GenesisTime), 0)
+	tests := []struct {
+		name     string
+		mode     string
+		interval time.Duration
+		base     time.Time
+		nows     []time.Duration // since base
+		expected []time.Duration // since base
+	}{
+		{
+			name:     "interval starts immediately",
+			mode:     "interval",
+			interval: time.Second * 10,
+			base:     minute.Add(time.Second * 7),
+			nows:     []time.Duration{0, time.Second * 3, time.Second * 10},
+			expected: []time.Duration{0, time.Second * 10, time.Second * 20},
+		},
+		{
+			name:     "interval skips missed ticks",
+			mode:     "interval",
+			interval: time.Second * 10,
+			base:     minute.Add(time.Second * 7),
+			nows:     []time.Duration{0, time.Second * 25, time.Second * 31},
+			expected: []time.Duration{0, time.Second * 30, time.Second * 40},
+		},
+		{
+			name:     "wall-clock ticks at full minutes",
+			mode:     "wall-clock",
+			interval: time.Minute,
+			base:     minute,
+			nows:     []time.Duration{time.Second * 10, time.Second * 60, time.Second * 250},
+			expected: []time.Duration{time.Second * 60, time.Second * 120, time.Second * 300},
+		},
+		{
+			name:     "wall-clock ticks at now if it is on a full minute",
+			mode:     "wall-clock",
+			interval: time.Minute,
+			base:     minute,
+			nows:     []time.Duration{0, 0},
+			expected: []time.Duration{0, time.Minute},
+		},
+		{
+			name:     "slot rounds the interval up to whole slots",
+			mode:     "slot",
+			interval: time.Second * 30,
+			base:     genesis,
+			nows:     []time.Duration{time.Second * 100, time.Second * 110, time.Second * 200},
+			expected: []time.Duration{time.Second * 108, time.Second * 144, time.Second * 216},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newScheduler(tt.mode, tt.interval, 0, tt.base)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i, now := range tt.nows {
+				got := s.next(tt.base.Add(now)).Sub(tt.base)
+				if got != tt.expected[i] {
+					t.Errorf("tick %v: got %v, expected %v", i, got, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	minute := time.Unix(1599999960, 0)
+	for i := 0; i < 100; i++ {
+		// the jitter is clamped to the interval
+		s, err := newScheduler("wall-clock", time.Minute, time.Hour, minute.Add(time.Duration(i)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s.offset < 0 || s.offset >= time.Minute {
+			t.Fatalf("got offset %v, expected it within the interval", s.offset)
+		}
+		first := s.next(minute)
+		if first != minute.Add(s.offset) {
+			t.Errorf("got first tick %v, expected %v", first, minute.Add(s.offset))
+		}
+		if second := s.next(first); second.Sub(first) != time.Minute {
+			t.Errorf("got ticks %v apart, expected %v", second.Sub(first), time.Minute)
+		}
+	}
+}
+
+func TestNewSchedulerInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		interval time.Duration
+	}{
+		{name: "unknown mode", mode: "cron", interval: time.Minute},
+		{name: "zero interval", mode: "interval", interval: 0},
+		{name: "negative interval", mode: "wall-clock", interval: -time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newScheduler(tt.mode, tt.interval, 0, time.Now())
+			if err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	promModel "github.com/prometheus/client_model/go"
+	promExpfmt "github.com/prometheus/common/expfmt"
+)
+
+// scrapeAcceptHeader prefers the protobuf-format, then OpenMetrics and then the text-format
+const scrapeAcceptHeader = `application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7,application/openmetrics-text;version=1.0.0;q=0.5,application/openmetrics-text;version=0.0.1;q=0.4,text/plain;version=0.0.4;q=0.3,*/*;q=0.1`
+
+// parseMetrics parses the body of a scrape-response by its Content-Type
+func parseMetrics(res *http.Response) (map[string]*promModel.MetricFamily, error) {
+	if promExpfmt.ResponseFormat(res.Header) == promExpfmt.FmtProtoDelim {
+		metricFamilies := map[string]*promModel.MetricFamily{}
+		decoder := promExpfmt.NewDecoder(res.Body, promExpfmt.FmtProtoDelim)
+		for {
+			mf := &promModel.MetricFamily{}
+			err := decoder.Decode(mf)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			metricFamilies[mf.GetName()] = mf
+		}
+		return metricFamilies, nil
+	}
+
+	var body io.Reader = res.Body
+	mediatype, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediatype == promExpfmt.OpenMetricsType {
+		text, err := openMetricsToText(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(text)
+	}
+
+	var parser promExpfmt.TextParser
+	return parser.TextToMetricFamilies(body)
+}
+
+// openMetricsToText converts the OpenMetrics-format to the prometheus text-format, see:
+// https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md
+//
+// Exemplars, timestamps (which are in seconds instead of milliseconds), _created-series,
+// HELP- and UNIT-lines are dropped. The families of counters and info-metrics are renamed
+// to the names of their samples (_total and _info) and the types which are not supported by
+// the text-format are mapped to gauge (stateset) or untyped (unknown, gaugehistogram).
+func openMetricsToText(r io.Reader) (string, error) {
+	var out strings.Builder
+	types := map[string]string{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if line == "# EOF" {
+			break
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) < 4 || fields[1] != "TYPE" {
+				continue
+			}
+			name, typ := fields[2], fields[3]
+			types[name] = typ
+			switch typ {
+			case "counter":
+				fmt.Fprintf(&out, "# TYPE %s_total counter\n", name)
+			case "info":
+				fmt.Fprintf(&out, "# TYPE %s_info gauge\n", name)
+			case "stateset":
+				fmt.Fprintf(&out, "# TYPE %s gauge\n", name)
+			case "gauge", "histogram", "summary":
+				fmt.Fprintf(&out, "# TYPE %s %s\n", name, typ)
+			}
+			continue
+		}
+
+		name, labels, rest, err := splitOpenMetricsSample(line)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasSuffix(name, "_created") {
+			switch types[strings.TrimSuffix(name, "_created")] {
+			case "counter", "histogram", "summary", "gaugehistogram":
+				continue
+			}
+		}
+		// drop the exemplar and the timestamp
+		if i := strings.Index(rest, "#"); i >= 0 {
+			rest = rest[:i]
+		}
+		values := strings.Fields(rest)
+		if len(values) == 0 {
+			return "", fmt.Errorf("invalid OpenMetrics sample without value: %v", line)
+		}
+		fmt.Fprintf(&out, "%s%s %s\n", name, labels, values[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// splitOpenMetricsSample splits a sample-line into the metric-name, the labels (including
+// the braces) and the rest of the line, label-values may contain escaped quotes
+func splitOpenMetricsSample(line string) (name, labels, rest string, err error) {
+	i := strings.IndexAny(line, "{ ")
+	if i < 0 {
+		return "", "", "", fmt.Errorf("invalid OpenMetrics sample: %v", line)
+	}
+	name = line[:i]
+	if line[i] == ' ' {
+		return name, "", line[i:], nil
+	}
+	inQuotes := false
+	for j := i + 1; j < len(line); j++ {
+		switch {
+		case inQuotes && line[j] == '\\':
+			j++
+		case line[j] == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && line[j] == '}':
+			return name, line[i : j+1], line[j+1:], nil
+		}
+	}
+	return "", "", "", fmt.Errorf("invalid OpenMetrics sample, unterminated labels: %v", line)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	promExpfmt "github.com/prometheus/common/expfmt"
+)
+
+func TestOpenMetricsToText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "counter is renamed to _total",
+			input: `# TYPE requests counter
+# HELP requests Total requests.
+requests_total 42
+`,
+			expected: `# TYPE requests_total counter
+requests_total 42
+`,
+		},
+		{
+			name: "info is renamed to _info gauge",
+			input: `# TYPE build info
+build_info{version="1.0.0"} 1
+`,
+			expected: `# TYPE build_info gauge
+build_info{version="1.0.0"} 1
+`,
+		},
+		{
+			name: "_created series are dropped",
+			input: `# TYPE requests counter
+requests_total 42
+requests_created 1.6e+09
+# TYPE latency histogram
+latency_bucket{le="+Inf"} 3
+latency_count 3
+latency_sum 1.5
+latency_created 1.6e+09
+`,
+			expected: `# TYPE requests_total counter
+requests_total 42
+# TYPE latency histogram
+latency_bucket{le="+Inf"} 3
+latency_count 3
+latency_sum 1.5
+`,
+		},
+		{
+			name: "_created of a gauge is kept",
+			input: `# TYPE items_created gauge
+items_created 7
+`,
+			expected: `# TYPE items_created gauge
+items_created 7
+`,
+		},
+		{
+			name: "exemplars and timestamps are dropped",
+			input: `# TYPE requests counter
+requests_total{code="200"} 42 1.6e+09 # {trace_id="abc"} 1 1.6e+09
+requests_total{code="500"} 1 # {trace_id="def"} 1
+# TYPE temperature gauge
+temperature 21.5 1.6e+09
+`,
+			expected: `# TYPE requests_total counter
+requests_total{code="200"} 42
+requests_total{code="500"} 1
+# TYPE temperature gauge
+temperature 21.5
+`,
+		},
+		{
+			name: "escaped quotes in labels",
+			input: `# TYPE peers gauge
+peers{agent="say \"hi\" } # 1",state="Connected"} 3
+`,
+			expected: `# TYPE peers gauge
+peers{agent="say \"hi\" } # 1",state="Connected"} 3
+`,
+		},
+		{
+			name: "nothing after # EOF is read",
+			input: `# TYPE peers gauge
+peers 3
+# EOF
+peers 4
+`,
+			expected: `# TYPE peers gauge
+peers 3
+`,
+		},
+		{
+			name: "unsupported types are mapped",
+			input: `# TYPE state stateset
+state{state="a"} 1
+# TYPE other unknown
+other 2
+`,
+			expected: `# TYPE state gauge
+state{state="a"} 1
+other 2
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := openMetricsToText(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if text != tt.expected {
+				t.Errorf("got:\n%s\nexpected:\n%s", text, tt.expected)
+			}
+			var parser promExpfmt.TextParser
+			_, err = parser.TextToMetricFamilies(strings.NewReader(text))
+			if err != nil {
+				t.Errorf("failed parsing converted text: %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenMetricsToTextInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "sample without value", input: "peers\n"},
+		{name: "unterminated labels", input: `peers{state="Connected" 3` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := openMetricsToText(strings.NewReader(tt.input))
+			if err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// flushData makes a last attempt to push the pending data on shutdown, if it fails the data is
+// persisted to the spool-file or the spill-dir of the buffer (if set) to be pushed on the next start
+func flushData(data []interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), options.ShutdownTimeout)
+	defer cancel()
+	err := sendData(ctx, data)
+	recordPush(err)
+	if err == nil {
+		logrus.WithFields(logrus.Fields{"records": len(data)}).Info("sent pending data on shutdown")
+		return nil
+	}
+	logrus.WithError(err).Warn("failed sending pending data on shutdown")
+	file := options.ShutdownSpoolFile
+	if file == "" && options.BufferPolicy == "spill" {
+		file = filepath.Join(options.BufferSpillDir, fmt.Sprintf("%020d.json", time.Now().UnixNano()))
+	}
+	if file == "" {
+		return fmt.Errorf("dropped %v records: %w", len(data), err)
+	}
+	err = spoolData(file, data)
+	if err != nil {
+		return fmt.Errorf("dropped %v records: %w", len(data), err)
+	}
+	logrus.WithFields(logrus.Fields{"records": len(data), "file": file}).Info("persisted pending data")
+	return nil
+}
+
+func spoolData(path string, data []interface{}) error {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed marshaling data: %w", err)
+	}
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, dataJSON, 0600)
+	if err != nil {
+		return fmt.Errorf("failed writing spool-file: %w", err)
+	}
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return fmt.Errorf("failed writing spool-file: %w", err)
+	}
+	return nil
+}
+
+// loadSpooledData reads and removes the data persisted on the last shutdown, it is sent
+// together with the first collected data
+func loadSpooledData(path string) ([]interface{}, error) {
+	dataJSON, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed reading spool-file: %w", err)
+	}
+	records := []json.RawMessage{}
+	err = json.Unmarshal(dataJSON, &records)
+	if err != nil {
+		return nil, fmt.Errorf("failed unmarshaling spool-file: %w", err)
+	}
+	err = os.Remove(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed removing spool-file: %w", err)
+	}
+	data := make([]interface{}, 0, len(records))
+	for _, r := range records {
+		data = append(data, r)
+	}
+	return data, nil
+}
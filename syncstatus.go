@@ -0,0 +1,60 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+type headSlotState struct {
+	slot      uint64
+	unchanged int  // number of consecutive intervals the head-slot did not advance
+	ahead     bool // whether the head-slot was ahead of the wall-clock-slot, to only warn once
+}
+
+var headSlotStates = struct {
+	sync.Mutex
+	m map[string]*headSlotState
+}{m: map[string]*headSlotState{}}
+
+// setBeaconnodeSyncData derives SyncEth2Synced from the head-slot of the beaconnode at the
+// given endpoint: the beaconnode is synced if its head is at most --sync.tolerance slots
+// behind the wall-clock-slot and did not stall for --sync.stall-intervals intervals
+func setBeaconnodeSyncData(endpoint string, data *BeaconnodeData) {
+	wallSlot := network.wallSlot(data.Timestamp)
+	slotsBehind := uint64(0)
+	if wallSlot > data.SyncBeaconHeadSlot {
+		slotsBehind = wallSlot - data.SyncBeaconHeadSlot
+	}
+
+	headSlotStates.Lock()
+	s, exists := headSlotStates.m[endpoint]
+	if !exists {
+		s = &headSlotState{slot: data.SyncBeaconHeadSlot}
+		headSlotStates.m[endpoint] = s
+	} else if s.slot == data.SyncBeaconHeadSlot {
+		s.unchanged++
+	} else {
+		s.slot = data.SyncBeaconHeadSlot
+		s.unchanged = 0
+	}
+	stalled := options.SyncStallIntervals > 0 && s.unchanged >= options.SyncStallIntervals
+	// a head ahead of the wall-clock (beyond clock-skew) means the wrong network is configured,
+	// slotsBehind and so sync_eth2_synced are meaningless then
+	ahead := data.SyncBeaconHeadSlot > wallSlot+1
+	if ahead && !s.ahead {
+		logrus.WithFields(logrus.Fields{"endpoint": endpoint, "headSlot": data.SyncBeaconHeadSlot, "wallSlot": wallSlot, "network": network.Name}).Warn("head-slot of the beaconnode is ahead of the current slot of the network, is --network set correctly?")
+	}
+	s.ahead = ahead
+	headSlotStates.Unlock()
+
+	data.SyncEth2Synced = data.SyncBeaconHeadSlot > 0 && slotsBehind <= options.SyncTolerance && !stalled
+
+	data.BeaconnodeSyncData = &BeaconnodeSyncData{
+		SyncBeaconHeadEpoch:   network.epoch(data.SyncBeaconHeadSlot),
+		SyncBeaconWallSlot:    wallSlot,
+		SyncBeaconWallEpoch:   network.epoch(wallSlot),
+		SyncBeaconSlotsBehind: slotsBehind,
+		SyncBeaconHeadStalled: stalled,
+	}
+}
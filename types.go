@@ -5,19 +5,44 @@ package main
 type CommonData struct {
 	Version         int64  `json:"version"`
 	Timestamp       uint64 `json:"timestamp"` // unix timestamp in milliseconds
-	Process         string `json:"process"`   // can be one of: validator, beaconnode, system
+	Process         string `json:"process"`   // can be one of: validator, beaconnode, execution, system
 	ExporterVersion string `json:"exporter_version"`
+	*CommonNetworkData
+}
+
+// CommonNetworkData contains the network of the node, so testnet nodes can be told apart from mainnet nodes.
+type CommonNetworkData struct {
+	Network string `json:"network"` // can be one of: mainnet, holesky, sepolia, gnosis, custom
 }
 
 type ProcessData struct {
 	CommonData
 	CPUProcessSecondsTotal     uint64 `json:"cpu_process_seconds_total"`
 	MemoryProcessBytes         uint64 `json:"memory_process_bytes"`
-	ClientName                 string `json:"client_name"` // can be one of: prysm, lighthouse, nimbus, teku
+	ClientName                 string `json:"client_name"` // can be one of: prysm, lighthouse, nimbus, teku, geth, nethermind, besu, erigon
 	ClientVersion              string `json:"client_version"`
 	ClientBuild                int64  `json:"client_build"`
 	SyncEth2FallbackConfigured bool   `json:"sync_eth2_fallback_configured"`
 	SyncEth2FallbackConnected  bool   `json:"sync_eth2_fallback_connected"`
+	*ProcessCPUData
+	*ProcessCgroupData
+}
+
+// The embedded *...Data structs of the records are not part of the spec, they are extended fields which
+// are only sent if the server supports them (see: --server.extended and stripExtendedData).
+
+// ProcessCPUData contains the cpu-time of the process, unlike the spec-field it is not truncated to whole seconds.
+type ProcessCPUData struct {
+	CPUProcessMillisecondsTotal uint64 `json:"cpu_process_milliseconds_total"`
+}
+
+// ProcessCgroupData contains the stats of the cgroup (container) of the process (see: --beaconnode.cgroup), limits are 0 if unlimited.
+type ProcessCgroupData struct {
+	CgroupCPUQuotaCores        float64 `json:"cgroup_cpu_quota_cores"`
+	CgroupCPUMillisecondsTotal uint64  `json:"cgroup_cpu_milliseconds_total"`
+	CgroupMemoryLimitBytes     uint64  `json:"cgroup_memory_limit_bytes"`
+	CgroupMemoryUsageBytes     uint64  `json:"cgroup_memory_usage_bytes"`
+	CgroupOOMKillsTotal        uint64  `json:"cgroup_oom_kills_total"`
 }
 
 type BeaconnodeData struct {
@@ -32,12 +57,75 @@ type BeaconnodeData struct {
 	SyncEth1FallbackConfigured      bool   `json:"sync_eth1_fallback_configured"`
 	SyncEth1FallbackConnected       bool   `json:"sync_eth1_fallback_connected"`
 	SlasherActive                   bool   `json:"slasher_active"`
+	*BeaconnodeSyncData
+	*BeaconnodeSampleData
+	*BeaconnodeRateData
+}
+
+// BeaconnodeRateData contains the rates of the counters between the last two collections.
+type BeaconnodeRateData struct {
+	// computed from network_libp2p_bytes_total_receive, which holds the received messages (p2p_message_received_total)
+	NetworkLibP2PMessagesReceivedPerSecond float64 `json:"network_libp2p_messages_received_per_second"`
+}
+
+// BeaconnodeSyncData contains the sync-status derived from the head-slot, see setBeaconnodeSyncData.
+type BeaconnodeSyncData struct {
+	SyncBeaconHeadEpoch   uint64 `json:"sync_beacon_head_epoch"`
+	SyncBeaconWallSlot    uint64 `json:"sync_beacon_wall_slot"`
+	SyncBeaconWallEpoch   uint64 `json:"sync_beacon_wall_epoch"`
+	SyncBeaconSlotsBehind uint64 `json:"sync_beacon_slots_behind"` // sync-distance
+	SyncBeaconHeadStalled bool   `json:"sync_beacon_head_stalled"`
+}
+
+// BeaconnodeSampleData aggregates the samples taken every --sample.interval since the last collection.
+type BeaconnodeSampleData struct {
+	NetworkPeersConnectedMin float64 `json:"network_peers_connected_min"`
+	NetworkPeersConnectedMax float64 `json:"network_peers_connected_max"`
+	NetworkPeersConnectedAvg float64 `json:"network_peers_connected_avg"`
+	SampleCount              int64   `json:"sample_count"`
+}
+
+type ExecutionData struct {
+	ProcessData
+	DiskChaindataBytesTotal uint64 `json:"disk_chaindata_bytes_total"`
+	NetworkPeersConnected   uint64 `json:"network_peers_connected"`
+	SyncEth1Synced          bool   `json:"sync_eth1_synced"`
+	SyncEth1CurrentBlock    uint64 `json:"sync_eth1_current_block"`
+	SyncEth1HighestBlock    uint64 `json:"sync_eth1_highest_block"`
 }
 
 type ValidatorData struct {
 	ProcessData
 	ValidatorTotal  int64 `json:"validator_total"`
 	ValidatorActive int64 `json:"validator_active"`
+	*ValidatorStatusData
+	*ValidatorPerformanceData
+}
+
+// ValidatorPerformanceData contains the attestation-performance of the previous epoch and the duty-totals
+// counted since the start of the client.
+type ValidatorPerformanceData struct {
+	AttestationsCorrectSource        int64   `json:"validator_attestations_correct_source"`
+	AttestationsCorrectTarget        int64   `json:"validator_attestations_correct_target"`
+	AttestationsCorrectHead          int64   `json:"validator_attestations_correct_head"`
+	AttestationsMissed               int64   `json:"validator_attestations_missed"`
+	AttestationsInclusionDistanceAvg float64 `json:"validator_attestations_inclusion_distance_avg"`
+	AttestationsSuccessfulTotal      uint64  `json:"validator_attestations_successful_total"`
+	AttestationsFailedTotal          uint64  `json:"validator_attestations_failed_total"`
+	ProposalsSuccessfulTotal         uint64  `json:"validator_proposals_successful_total"`
+	ProposalsFailedTotal             uint64  `json:"validator_proposals_failed_total"`
+}
+
+// ValidatorStatusData contains the validators per lifecycle-state and their total balance.
+type ValidatorStatusData struct {
+	ValidatorUnknown          int64  `json:"validator_unknown"`
+	ValidatorDeposited        int64  `json:"validator_deposited"`
+	ValidatorPending          int64  `json:"validator_pending"`
+	ValidatorExiting          int64  `json:"validator_exiting"`
+	ValidatorSlashing         int64  `json:"validator_slashing"`
+	ValidatorExited           int64  `json:"validator_exited"`
+	ValidatorSlashed          int64  `json:"validator_slashed"` // validators with the slashed-flag set, regardless of their state
+	ValidatorBalanceTotalGwei uint64 `json:"validator_balance_total_gwei"`
 }
 
 type SystemData struct {
@@ -61,4 +149,81 @@ type SystemData struct {
 	NetworkNodeBytesTotalTransmit uint64 `json:"network_node_bytes_total_transmit"`
 	MiscNodeBootTSSeconds         uint64 `json:"misc_node_boot_ts_seconds"`
 	MiscOS                        string `json:"misc_os"`
+	*SystemCPUData
+	*SystemNetworkData
+	*SystemHostData
+	*SystemClockData
+	*SystemSampleData
+	*SystemRateData
+
+	cpuNodeSeconds cpuSeconds
+}
+
+// SystemRateData contains the rates of the counters between the last two collections.
+type SystemRateData struct {
+	CPUNodeUtilizationPercent         float64 `json:"cpu_node_utilization_percent"`
+	NetworkNodeReceiveBytesPerSecond  float64 `json:"network_node_receive_bytes_per_second"`
+	NetworkNodeTransmitBytesPerSecond float64 `json:"network_node_transmit_bytes_per_second"`
+	DiskNodeReadsPerSecond            float64 `json:"disk_node_reads_per_second"`
+	DiskNodeWritesPerSecond           float64 `json:"disk_node_writes_per_second"`
+}
+
+// SystemCPUData contains the cpu-times, unlike the spec-fields they are not truncated to whole seconds.
+type SystemCPUData struct {
+	CPUNodeSystemMillisecondsTotal uint64 `json:"cpu_node_system_milliseconds_total"`
+	CPUNodeUserMillisecondsTotal   uint64 `json:"cpu_node_user_milliseconds_total"`
+	CPUNodeIOWaitMillisecondsTotal uint64 `json:"cpu_node_iowait_milliseconds_total"`
+	CPUNodeIdleMillisecondsTotal   uint64 `json:"cpu_node_idle_milliseconds_total"`
+}
+
+// SystemNetworkData contains the counters of the interfaces selected by --system.net-interfaces.
+type SystemNetworkData struct {
+	NetworkNodeInterfaces map[string]NetworkInterfaceData `json:"network_node_interfaces"`
+}
+
+type NetworkInterfaceData struct {
+	BytesTotalReceive  uint64 `json:"bytes_total_receive"`
+	BytesTotalTransmit uint64 `json:"bytes_total_transmit"`
+}
+
+// SystemHostData contains additional host-metrics, metrics which are not available on the system are empty.
+type SystemHostData struct {
+	CPUNodeLoad1                  float64           `json:"cpu_node_load_1"`
+	CPUNodeLoad5                  float64           `json:"cpu_node_load_5"`
+	CPUNodeLoad15                 float64           `json:"cpu_node_load_15"`
+	MemoryNodeBytesAvailable      uint64            `json:"memory_node_bytes_available"` // MemAvailable, the memory available without swapping
+	MemoryNodeSwapBytesTotal      uint64            `json:"memory_node_swap_bytes_total"`
+	MemoryNodeSwapBytesUsed       uint64            `json:"memory_node_swap_bytes_used"`
+	MiscNodeCPUTemperatureCelsius float64           `json:"misc_node_cpu_temperature_celsius,omitempty"`
+	MiscNodeFileDescriptorsOpen   uint64            `json:"misc_node_file_descriptors_open"`
+	MiscNodeFileDescriptorsMax    uint64            `json:"misc_node_file_descriptors_max"`
+	NetworkNodeTCPConnections     map[string]uint64 `json:"network_node_tcp_connections"` // by state, eg: established, time_wait
+}
+
+// SystemClockData contains the synchronization-state of the clock. The kernel-offset is the part of the
+// offset the kernel is still slewing away, not the offset to a time-server.
+// The timestamps of the records are not corrected, all records of a collection share the timestamp of the
+// system-data, so misc_node_clock_synced tells whether the timestamps of the collection can be trusted.
+type SystemClockData struct {
+	MiscNodeClockSynced                bool    `json:"misc_node_clock_synced"`
+	MiscNodeClockKernelOffsetSeconds   float64 `json:"misc_node_clock_kernel_offset_seconds"`
+	MiscNodeClockMaxErrorSeconds       float64 `json:"misc_node_clock_max_error_seconds"`
+	MiscNodeClockEstimatedErrorSeconds float64 `json:"misc_node_clock_estimated_error_seconds"`
+}
+
+// SystemSampleData aggregates the samples taken every --sample.interval since the last collection.
+type SystemSampleData struct {
+	CPUNodeUtilizationPercentMin         float64 `json:"cpu_node_utilization_percent_min"`
+	CPUNodeUtilizationPercentMax         float64 `json:"cpu_node_utilization_percent_max"`
+	CPUNodeUtilizationPercentAvg         float64 `json:"cpu_node_utilization_percent_avg"`
+	MemoryNodeBytesFreeMin               float64 `json:"memory_node_bytes_free_min"`
+	MemoryNodeBytesFreeMax               float64 `json:"memory_node_bytes_free_max"`
+	MemoryNodeBytesFreeAvg               float64 `json:"memory_node_bytes_free_avg"`
+	NetworkNodeReceiveBytesPerSecondMin  float64 `json:"network_node_receive_bytes_per_second_min"`
+	NetworkNodeReceiveBytesPerSecondMax  float64 `json:"network_node_receive_bytes_per_second_max"`
+	NetworkNodeReceiveBytesPerSecondAvg  float64 `json:"network_node_receive_bytes_per_second_avg"`
+	NetworkNodeTransmitBytesPerSecondMin float64 `json:"network_node_transmit_bytes_per_second_min"`
+	NetworkNodeTransmitBytesPerSecondMax float64 `json:"network_node_transmit_bytes_per_second_max"`
+	NetworkNodeTransmitBytesPerSecondAvg float64 `json:"network_node_transmit_bytes_per_second_avg"`
+	SampleCount                          int64   `json:"sample_count"`
 }
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// validatorAPIChunkSize is the number of pubkeys queried per request to the beacon-api
+const validatorAPIChunkSize = 50
+
+// validatorAPIClientNames are the valid values of --validator.type if only --validator.api is set
+var validatorAPIClientNames = map[string]bool{"prysm": true, "lighthouse": true, "teku": true, "nimbus": true, "lodestar": true}
+
+type keymanagerKeystoresResponse struct {
+	Data []struct {
+		ValidatingPubkey string `json:"validating_pubkey"`
+	} `json:"data"`
+}
+
+type keymanagerRemotekeysResponse struct {
+	Data []struct {
+		Pubkey string `json:"pubkey"`
+	} `json:"data"`
+}
+
+type beaconValidatorsResponse struct {
+	Data []struct {
+		Index     string `json:"index"`
+		Balance   uint64 `json:"balance,string"`
+		Status    string `json:"status"`
+		Validator struct {
+			Pubkey  string `json:"pubkey"`
+			Slashed bool   `json:"slashed"`
+		} `json:"validator"`
+	} `json:"data"`
+}
+
+// getValidatorAPIData collects the validator-data via the keymanager-api and the beacon-api, the client
+// is detected via the beacon-api if client is empty or "auto"
+func getValidatorAPIData(ctx context.Context, c ClientEndpoint, client string, ts uint64) (*ValidatorData, error) {
+	data := &ValidatorData{}
+
+	// CommonData
+	data.Version = specVersion
+	data.Timestamp = ts
+	data.ExporterVersion = exporterVersion
+	data.CommonNetworkData = &CommonNetworkData{Network: network.Name}
+	data.Process = "validator"
+
+	// ProcessData
+	data.ClientName = client
+	if client == "" || client == "auto" {
+		// validator-clients are usually run together with the beaconnode of the same client
+		client, err := getBeaconAPIClient(ctx, c.BeaconAPIAddress, &options.BeaconnodeAuth)
+		if err != nil {
+			return nil, fmt.Errorf("failed detecting client via beacon-api: %w", err)
+		}
+		data.ClientName = client
+	}
+	data.ClientBuild = 0
+	data.SyncEth2FallbackConfigured = false
+	data.SyncEth2FallbackConnected = false
+
+	// ValidatorData
+	err := setValidatorDataFromAPI(ctx, c, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// setValidatorDataFromAPI sets the validator-counts of data by looking up the keys
+// of the validator-client via the keymanager-api and their statuses via the beacon-api
+func setValidatorDataFromAPI(ctx context.Context, c ClientEndpoint, data *ValidatorData) error {
+	counter, err := getValidatorStatusCounter(ctx, c)
+	if err != nil {
+		return err
+	}
+	counter.setValidatorData(data)
+	return nil
+}
+
+func getValidatorStatusCounter(ctx context.Context, c ClientEndpoint) (*validatorStatusCounter, error) {
+	pubkeys, err := getKeymanagerPubkeys(ctx, c.KeymanagerAddress, &c.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	counter := newValidatorStatusCounter()
+
+	for i := 0; i < len(pubkeys); i += validatorAPIChunkSize {
+		end := i + validatorAPIChunkSize
+		if end > len(pubkeys) {
+			end = len(pubkeys)
+		}
+		res := &beaconValidatorsResponse{}
+		err = getAPIJSON(ctx, fmt.Sprintf("%s/eth/v1/beacon/states/head/validators?id=%s", strings.TrimSuffix(c.BeaconAPIAddress, "/"), strings.Join(pubkeys[i:end], ",")), &options.BeaconnodeAuth, "", res)
+		if err != nil && err != errValidatorAPINotFound {
+			return nil, fmt.Errorf("failed getting validators from beacon-api: %w", err)
+		}
+		// validators which are unknown to the beacon-node are not part of the response
+		for _, v := range res.Data {
+			counter.add(validatorStateFromBeaconAPIStatus(v.Status), v.Validator.Slashed)
+			counter.addBalance(v.Balance)
+		}
+		for j := int64(len(res.Data)); j < int64(end-i); j++ {
+			counter.add(validatorStateUnknown, false)
+		}
+	}
+
+	return counter, nil
+}
+
+// getKeymanagerPubkeys returns the pubkeys of all local and remote keys of the validator-client
+func getKeymanagerPubkeys(ctx context.Context, endpoint string, auth *EndpointAuth) ([]string, error) {
+	token := ""
+	if options.ValidatorAPITokenFile != "" {
+		tokenBytes, err := ioutil.ReadFile(options.ValidatorAPITokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading validator.api-token-file: %w", err)
+		}
+		token = strings.TrimSpace(string(tokenBytes))
+	}
+
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	pubkeys := []string{}
+
+	keystores := &keymanagerKeystoresResponse{}
+	err := getAPIJSON(ctx, endpoint+"/eth/v1/keystores", auth, token, keystores)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting keystores from keymanager-api: %w", err)
+	}
+	for _, k := range keystores.Data {
+		pubkeys = append(pubkeys, k.ValidatingPubkey)
+	}
+
+	// not every client supports remote keys, ignore it if the route does not exist
+	remotekeys := &keymanagerRemotekeysResponse{}
+	err = getAPIJSON(ctx, endpoint+"/eth/v1/remotekeys", auth, token, remotekeys)
+	if err != nil && err != errValidatorAPINotFound {
+		return nil, fmt.Errorf("failed getting remotekeys from keymanager-api: %w", err)
+	}
+	for _, k := range remotekeys.Data {
+		pubkeys = append(pubkeys, k.Pubkey)
+	}
+
+	return pubkeys, nil
+}
+
+var errValidatorAPINotFound = errors.New("not found")
+
+// getAPIJSON gets url of a beacon- or keymanager-api with the auth of the endpoint, token (of the keymanager-api) overrides its Authorization-header
+func getAPIJSON(ctx context.Context, url string, auth *EndpointAuth, token string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	err = auth.apply(req)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := scrapeHTTPClient.Do(req)
+	if err != nil {
+		return newScrapeRequestError(url, err)
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return errValidatorAPINotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("got error-response: %s: %w", body, &ScrapeError{URL: url, Reason: ScrapeErrorStatus, StatusCode: res.StatusCode})
+	}
+	err = json.Unmarshal(body, result)
+	if err != nil {
+		return &ScrapeError{URL: url, Reason: ScrapeErrorParse, Err: err}
+	}
+	return nil
+}
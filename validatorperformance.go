@@ -0,0 +1,106 @@
+package main
+
+import (
+	promModel "github.com/prometheus/client_model/go"
+)
+
+// validatorPerformanceMetricNames maps the values of ValidatorPerformanceData to the
+// metric-names of the validator-monitor of a client. The per-validator gauges are
+// expected to contain one series per validator with the result of the previous epoch.
+type validatorPerformanceMetricNames struct {
+	// per-validator gauges (0 or 1)
+	CorrectlyVotedSource string
+	CorrectlyVotedTarget string
+	CorrectlyVotedHead   string
+	// per-validator gauge
+	InclusionDistance string
+	// counters
+	SuccessfulAttestations string
+	FailedAttestations     string
+	SuccessfulProposals    string
+	FailedProposals        string
+}
+
+var prysmValidatorPerformanceMetricNames = validatorPerformanceMetricNames{
+	CorrectlyVotedSource:   "validator_correctly_voted_source",
+	CorrectlyVotedTarget:   "validator_correctly_voted_target",
+	CorrectlyVotedHead:     "validator_correctly_voted_head",
+	InclusionDistance:      "validator_inclusion_distance",
+	SuccessfulAttestations: "validator_successful_attestations",
+	FailedAttestations:     "validator_failed_attestations",
+	SuccessfulProposals:    "validator_successful_proposals",
+	FailedProposals:        "validator_failed_proposals",
+}
+
+// getValidatorPerformanceData returns nil if the client does not export any validator-monitor metrics
+func getValidatorPerformanceData(metrics map[string]*promModel.MetricFamily, names validatorPerformanceMetricNames) *ValidatorPerformanceData {
+	found := false
+	data := &ValidatorPerformanceData{}
+
+	source, exists := metrics[names.CorrectlyVotedSource]
+	if exists {
+		found = true
+		for _, m := range source.GetMetric() {
+			if getMetricValue(m) == 1 {
+				data.AttestationsCorrectSource++
+			} else {
+				// without a correct source-vote the attestation was not included in time
+				data.AttestationsMissed++
+			}
+		}
+	}
+
+	target, exists := metrics[names.CorrectlyVotedTarget]
+	if exists {
+		found = true
+		for _, m := range target.GetMetric() {
+			if getMetricValue(m) == 1 {
+				data.AttestationsCorrectTarget++
+			}
+		}
+	}
+
+	head, exists := metrics[names.CorrectlyVotedHead]
+	if exists {
+		found = true
+		for _, m := range head.GetMetric() {
+			if getMetricValue(m) == 1 {
+				data.AttestationsCorrectHead++
+			}
+		}
+	}
+
+	inclusionDistance, exists := metrics[names.InclusionDistance]
+	if exists {
+		found = true
+		sum := float64(0)
+		count := 0
+		for _, m := range inclusionDistance.GetMetric() {
+			// a distance of 0 means the attestation was not included
+			if v := getMetricValue(m); v > 0 {
+				sum += v
+				count++
+			}
+		}
+		if count > 0 {
+			data.AttestationsInclusionDistanceAvg = sum / float64(count)
+		}
+	}
+
+	for name, v := range map[string]*uint64{
+		names.SuccessfulAttestations: &data.AttestationsSuccessfulTotal,
+		names.FailedAttestations:     &data.AttestationsFailedTotal,
+		names.SuccessfulProposals:    &data.ProposalsSuccessfulTotal,
+		names.FailedProposals:        &data.ProposalsFailedTotal,
+	} {
+		if _, exists := metrics[name]; exists {
+			found = true
+			*v = uint64(getMetricValueSumFromFamilyMap(metrics, name))
+		}
+	}
+
+	if !found {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+)
+
+// validatorState is the lifecycle-state of a validator, the values match the
+// ValidatorStatus-enum of prysm which is exported by the validator_statuses-metric
+type validatorState int
+
+const (
+	validatorStateUnknown validatorState = iota
+	validatorStateDeposited
+	validatorStatePending
+	validatorStateActive
+	validatorStateExiting
+	validatorStateSlashing
+	validatorStateExited
+	validatorStateInvalid
+	validatorStatePartiallyDeposited
+)
+
+// validatorStateFromBeaconAPIStatus maps the statuses of the beacon-api to the lifecycle-states, see:
+// https://github.com/ethereum/beacon-APIs
+func validatorStateFromBeaconAPIStatus(status string) validatorState {
+	switch status {
+	case "pending_initialized":
+		return validatorStateDeposited
+	case "pending_queued":
+		return validatorStatePending
+	case "active_ongoing":
+		return validatorStateActive
+	case "active_exiting":
+		return validatorStateExiting
+	case "active_slashed":
+		return validatorStateSlashing
+	}
+	if strings.HasPrefix(status, "exited_") || strings.HasPrefix(status, "withdrawal_") {
+		return validatorStateExited
+	}
+	return validatorStateUnknown
+}
+
+// validatorStatusCounter counts validators by their lifecycle-state and sums up their balances
+type validatorStatusCounter struct {
+	total       int64
+	states      map[validatorState]int64
+	slashed     int64
+	balanceGwei uint64
+}
+
+func newValidatorStatusCounter() *validatorStatusCounter {
+	return &validatorStatusCounter{states: map[validatorState]int64{}}
+}
+
+func (c *validatorStatusCounter) add(state validatorState, slashed bool) {
+	c.total++
+	switch state {
+	case validatorStateInvalid:
+		state = validatorStateUnknown
+	case validatorStatePartiallyDeposited:
+		state = validatorStateDeposited
+	}
+	c.states[state]++
+	if slashed {
+		c.slashed++
+	}
+}
+
+func (c *validatorStatusCounter) addBalance(balanceGwei uint64) {
+	c.balanceGwei += balanceGwei
+}
+
+// setValidatorData sets the counts of data, the breakdown by lifecycle-state is set on the
+// extended fields
+func (c *validatorStatusCounter) setValidatorData(data *ValidatorData) {
+	data.ValidatorTotal = c.total
+	data.ValidatorActive = c.states[validatorStateActive]
+	data.ValidatorStatusData = &ValidatorStatusData{
+		ValidatorUnknown:          c.states[validatorStateUnknown],
+		ValidatorDeposited:        c.states[validatorStateDeposited],
+		ValidatorPending:          c.states[validatorStatePending],
+		ValidatorExiting:          c.states[validatorStateExiting],
+		ValidatorSlashing:         c.states[validatorStateSlashing],
+		ValidatorExited:           c.states[validatorStateExited],
+		ValidatorSlashed:          c.slashed,
+		ValidatorBalanceTotalGwei: c.balanceGwei,
+	}
+}